@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	gndict "github.com/gnames/gndict/pkg"
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// buildsCmd groups commands that manage builds of dictionaries.
+var buildsCmd = &cobra.Command{
+	Use:   "builds",
+	Short: "Manages builds of dictionaries",
+	Long: `Every run of gndict saves dictionaries to a new build directory
+'builds/<id>' in the CacheDir. The 'dict' directory points to the current
+build and changes only after a successful build.`,
+}
+
+// buildsListCmd shows all builds.
+var buildsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Shows builds of dictionaries",
+	Long: `Shows builds of dictionaries from the oldest to the newest. The current
+build is marked with '*'. Builds that failed checks after dictionaries were
+created are marked as 'failed'.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, dict := buildsDict(cmd)
+		bs, err := dict.Builds()
+		if err != nil {
+			err = fmt.Errorf("-> dict.Builds: %w", err)
+			log.Fatal().Err(err).Msg("Cannot list builds")
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, v := range bs {
+			mark := " "
+			if v.Current {
+				mark = "*"
+			}
+			status := ""
+			if !v.Activated && !v.Current {
+				status = "failed"
+			}
+			fmt.Fprintf(w, "%s %s\t%s\t%s\n",
+				mark, v.ID, v.Created.Local().Format("2006-01-02 15:04:05"), status)
+		}
+		w.Flush()
+	},
+}
+
+// buildsRollbackCmd makes one of the previous builds current.
+var buildsRollbackCmd = &cobra.Command{
+	Use:   "rollback [id]",
+	Short: "Makes a previous build current",
+	Long: `Makes a build current. Without an argument the newest successful build
+made before the current one is used.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_, dict := buildsDict(cmd)
+		var id string
+		if len(args) > 0 {
+			id = args[0]
+		}
+		id, err := dict.Rollback(id)
+		if err != nil {
+			err = fmt.Errorf("-> dict.Rollback: %w", err)
+			log.Fatal().Err(err).Msg("Cannot roll back")
+		}
+		log.Info().Msgf("Build %s is current", id)
+	},
+}
+
+// buildsPruneCmd removes old builds.
+var buildsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Removes old builds",
+	Long: `Removes all builds except the newest ones and the current one. The
+number of kept builds is set by --keep or the KeepBuilds setting.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cmd.Flags().Changed("keep") {
+			opts = append(opts, config.OptKeepBuilds(keepFlag(cmd)))
+		}
+		cfg, dict := buildsDict(cmd)
+		if cfg.KeepBuilds == 0 {
+			log.Info().Msg("KeepBuilds is 0, all builds are kept")
+			return
+		}
+		ids, err := dict.Prune(cfg.KeepBuilds)
+		if err != nil {
+			err = fmt.Errorf("-> dict.Prune: %w", err)
+			log.Fatal().Err(err).Msg("Cannot remove builds")
+		}
+		log.Info().Msgf("Removed %d builds %s", len(ids), strings.Join(ids, ", "))
+	},
+}
+
+// buildsDict creates configuration and DictGen for builds commands.
+func buildsDict(cmd *cobra.Command) (config.Config, gndict.DictGen) {
+	if scope := scopeFlag(cmd); scope != "" {
+		opts = append(opts, config.OptScope(scope))
+	}
+	cfg := newConfig()
+	return cfg, newDict(cfg, nil, nil, nil)
+}
+
+func init() {
+	rootCmd.AddCommand(buildsCmd)
+	buildsCmd.AddCommand(buildsListCmd, buildsRollbackCmd, buildsPruneCmd)
+	buildsPruneCmd.Flags().IntP("keep", "k", 0,
+		"Number of the newest builds to keep")
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	gndict "github.com/gnames/gndict/pkg"
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd groups commands that inspect and clean the cache.
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspects and cleans the cache",
+	Long: `Every stage of gndict saves files to the CacheDir: download saves dumps
+of the database, preprocess saves uninomials, genera, species etc., and
+output saves builds of dictionaries.`,
+}
+
+// cacheStatusCmd shows files of every stage.
+var cacheStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Shows files of the cache",
+	Long: `Shows files created by every stage of the pipeline with their sizes,
+numbers of lines and ages. For the 'dict' directory the number of entries
+in all dictionaries of the current build is shown.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, dict := cacheDict(cmd)
+		res, err := dict.CacheStatus(cmd.Context())
+		if err != nil {
+			err = fmt.Errorf("-> dict.CacheStatus: %w", err)
+			log.Fatal().Err(err).Msg("Cannot read the cache")
+		}
+
+		fmt.Printf("%s\n\n", cfg.WorkDir())
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STAGE\tFILE\tSIZE\tLINES\tAGE\t")
+		now := time.Now()
+		for _, v := range res {
+			if !v.Exists() {
+				fmt.Fprintf(w, "%s\t%s\t-\t-\tmissing\t\n", v.Stage, v.Name)
+				continue
+			}
+			// show compression extensions of files and the current build.
+			name := filepath.Base(v.Path)
+			if v.Stage == gndict.StageOutput {
+				name = v.Name + " -> " + name
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t\n",
+				v.Stage, name, formatSize(v.Size), v.Lines,
+				formatAge(now.Sub(v.Modified)))
+		}
+		w.Flush()
+	},
+}
+
+// cacheCleanCmd removes files of stages.
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Removes files of the cache",
+	Long: `Removes files created by stages of the pipeline. Without --stage files
+of all stages are removed. The next run of gndict creates the removed
+files again, for example, cleaning the 'download' stage makes the next run
+download names from the database. Cleaning the 'output' stage removes all
+builds and the report of the last build. The CurationFile is never removed.
+
+Examples:
+  gndict cache clean --stage preprocess
+  gndict cache clean --stage download,preprocess`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, dict := cacheDict(cmd)
+		stages, _ := cmd.Flags().GetStringSlice("stage")
+		res, err := dict.CleanCache(stages...)
+		if err != nil {
+			err = fmt.Errorf("-> dict.CleanCache: %w", err)
+			log.Fatal().Err(err).Msg("Cannot clean the cache")
+		}
+		for _, v := range res {
+			log.Info().Msgf("Removed %s", v)
+		}
+		log.Info().Msgf("Removed %d files", len(res))
+	},
+}
+
+// cacheDict creates configuration and DictGen for cache commands.
+func cacheDict(cmd *cobra.Command) (config.Config, gndict.DictGen) {
+	if scope := scopeFlag(cmd); scope != "" {
+		opts = append(opts, config.OptScope(scope))
+	}
+	cfg := newConfig()
+	return cfg, newDict(cfg, nil, nil, nil)
+}
+
+// formatSize shows a number of bytes in human-readable units.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for i := n / unit; i >= unit; i /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatAge shows a duration in the largest suitable units.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheStatusCmd, cacheCleanCmd)
+	cacheCleanCmd.Flags().StringSlice("stage", nil,
+		"Stages to clean: "+strings.Join(gndict.Stages, ", "))
+}
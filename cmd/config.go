@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/gnames/gnsys"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// configCmd groups commands that create and show configuration.
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Creates and shows configuration",
+	Long: `Settings of gndict are taken from flags, GNDICT_* environment variables,
+the config file and defaults, in this order.`,
+}
+
+// configInitCmd creates a config file.
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Creates a config file",
+	Long: `Creates a config file with all settings commented out and documented.
+By default the file is ~/.config/gndict.yaml. An existing file is not
+overwritten without --force.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		path, _ := cmd.Flags().GetString("path")
+		if path == "" {
+			var err error
+			path, err = defaultConfigPath()
+			if err != nil {
+				log.Fatal().Err(err).Msg("Cannot create config file")
+			}
+		}
+		force, _ := cmd.Flags().GetBool("force")
+		if ok, _ := gnsys.FileExists(path); ok && !force {
+			log.Fatal().Msgf("Config file %s exists, use --force to overwrite it", path)
+		}
+
+		err := createConfig(path)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Cannot create config file")
+		}
+		log.Info().Msgf("Created config file %s", path)
+	},
+}
+
+// configShowCmd shows the resolved configuration.
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Shows settings and where they come from",
+	Long: `Shows settings that gndict uses after flags, environment variables,
+the config file and defaults are combined. The source of every setting is
+shown beside its value. Passwords are masked.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if scope := scopeFlag(cmd); scope != "" {
+			opts = append(opts, config.OptScope(scope))
+		}
+		cfg := newConfig()
+
+		file := viper.ConfigFileUsed()
+		if ok, _ := gnsys.FileExists(file); !ok {
+			file = "none"
+		}
+		fmt.Printf("Config file: %s\n", file)
+		if profile != "" {
+			fmt.Printf("Profile: %s\n", profile)
+		}
+		fmt.Println()
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+		v := reflect.ValueOf(cfg)
+		t := v.Type()
+		for i := range t.NumField() {
+			name := t.Field(i).Name
+			val := v.Field(i)
+			if val.Kind() != reflect.Map {
+				fmt.Fprintf(w, "%s\t%s\t%s\n",
+					name, formatSetting(name, val), settingSource(cmd, name))
+				continue
+			}
+			keys := val.MapKeys()
+			slices.SortFunc(keys, func(a, b reflect.Value) int {
+				return cmp.Compare(a.String(), b.String())
+			})
+			for _, k := range keys {
+				fmt.Fprintf(w, "%s.%s\t%+v\t%s\n", name, k.String(),
+					val.MapIndex(k).Interface(), mapSource(name, k.String()))
+			}
+		}
+		w.Flush()
+	},
+}
+
+// settingSource tells where the value of a setting comes from: a flag,
+// an environment variable, the profile, the config file, or the default.
+func settingSource(cmd *cobra.Command, key string) string {
+	res := valueSource(cmd, key)
+	if key == "CacheDir" && profile != "" && !profileCacheDir() {
+		res += " + profile " + profile
+	}
+	return res
+}
+
+// valueSource finds where the value of a setting is set.
+func valueSource(cmd *cobra.Command, key string) string {
+	flag := settingFlags[key]
+	if key == "Scope" {
+		flag = "scope"
+	}
+	if flag != "" && cmd.Flags().Changed(flag) {
+		return "flag --" + flag
+	}
+	if f, ok := reflect.TypeOf(cfgData{}).FieldByName(key); ok &&
+		f.Type.Kind() != reflect.Map {
+		if _, ok := os.LookupEnv(envName(key)); ok {
+			return "env " + envName(key)
+		}
+	}
+	if _, ok := profileSettings[strings.ToLower(key)]; ok {
+		return "profile " + profile
+	}
+	if viper.InConfig(key) {
+		return "file"
+	}
+	return "default"
+}
+
+// mapSource tells if an entry of a map setting comes from the profile, the
+// config file or from defaults. Keys of the config file might omit the '.csv' extension.
+func mapSource(name, key string) string {
+	// viper keeps keys in lower case.
+	keys := []string{
+		strings.ToLower(key),
+		strings.ToLower(strings.TrimSuffix(key, ".csv")),
+	}
+	pm, _ := profileSettings[strings.ToLower(name)].(map[string]any)
+	m := viper.GetStringMap(name)
+	for _, v := range keys {
+		if _, ok := pm[v]; ok {
+			return "profile " + profile
+		}
+		if _, ok := m[v]; ok {
+			return "file"
+		}
+	}
+	return "default"
+}
+
+// formatSetting shows the value of a setting, passwords are masked.
+func formatSetting(name string, val reflect.Value) string {
+	if name == "PgPass" && val.String() != "" {
+		return "********"
+	}
+	res := fmt.Sprintf("%v", val.Interface())
+	if res == "" {
+		return `""`
+	}
+	return res
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configInitCmd, configShowCmd)
+	configInitCmd.Flags().String("path", "",
+		"Path to the new config file (default ~/.config/gndict.yaml)")
+	configInitCmd.Flags().Bool("force", false,
+		"Overwrite an existing config file")
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gnames/gndict/internal/io/sysio"
+	gndict "github.com/gnames/gndict/pkg"
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// curateCmd helps to find and review candidates for blacklists.
+var curateCmd = &cobra.Command{
+	Use:   "curate",
+	Short: "Reviews candidates for blacklists",
+	Long: `Finds candidates for blacklists: high-count epithets that are common
+words, uninomials that look like English words, and words of 'in'
+dictionaries that are common words.
+
+Without flags it asks to accept or reject every candidate. With --out it
+saves candidates to a TSV file, where the last column should be filled with
+'accept' or 'reject'. The file is then applied with --apply.
+
+Decisions are appended to the CurationFile and are used on the next build.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if scope := scopeFlag(cmd); scope != "" {
+			opts = append(opts, config.OptScope(scope))
+		}
+		cfg := newConfig()
+		sys := sysio.New(cfg)
+		dict := newDict(cfg, nil, sys, nil)
+
+		if path, _ := cmd.Flags().GetString("apply"); path != "" {
+			ds, err := readDecisions(path)
+			if err != nil {
+				log.Fatal().Err(err).Msgf("Cannot read decisions from %s", path)
+			}
+			saveDecisions(dict, ds)
+			return
+		}
+
+		minCount, _ := cmd.Flags().GetInt("min-count")
+		cs, err := dict.Curate(cmd.Context(), minCount)
+		if err != nil {
+			err = fmt.Errorf("-> dict.Curate: %w", err)
+			log.Fatal().Err(err).Msg("Cannot find candidates")
+		}
+		log.Info().Msgf("Found %d candidates", len(cs))
+
+		if path, _ := cmd.Flags().GetString("out"); path != "" {
+			err = writeCandidates(path, cs)
+			if err != nil {
+				log.Fatal().Err(err).Msgf("Cannot write candidates to %s", path)
+			}
+			log.Info().Msgf("Candidates are saved to %s", path)
+			return
+		}
+
+		saveDecisions(dict, reviewCandidates(cs))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(curateCmd)
+	curateCmd.Flags().IntP("min-count", "m", 100,
+		"Smallest count of common-word epithets to propose")
+	curateCmd.Flags().StringP("out", "o", "",
+		"Save candidates to a TSV file instead of asking")
+	curateCmd.Flags().StringP("apply", "a", "",
+		"Apply decisions from a TSV file created with --out")
+}
+
+// reviewCandidates asks a curator about every candidate.
+func reviewCandidates(cs []gndict.Candidate) []gndict.Decision {
+	var res []gndict.Decision
+	in := bufio.NewReader(os.Stdin)
+	for i, v := range cs {
+		fmt.Printf("[%d/%d] %s: %s (count %d, %s) accept? [y/n/s/q] ",
+			i+1, len(cs), v.List, v.Word, v.Count, v.Reason)
+		answer, err := in.ReadString('\n')
+		if err != nil {
+			break
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			res = append(res, gndict.Decision{List: v.List, Word: v.Word, Accept: true})
+		case "n", "no":
+			res = append(res, gndict.Decision{List: v.List, Word: v.Word})
+		case "q", "quit":
+			return res
+		}
+	}
+	return res
+}
+
+// writeCandidates saves candidates to a TSV file with an empty decision
+// column.
+func writeCandidates(path string, cs []gndict.Candidate) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintln(w, "List\tWord\tCount\tReason\tDecision")
+	for _, v := range cs {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t\n", v.List, v.Word, v.Count, v.Reason)
+	}
+	if err = w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readDecisions reads a TSV file created by writeCandidates. Rows without
+// a decision are skipped.
+func readDecisions(path string) ([]gndict.Decision, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var res []gndict.Decision
+	for i, v := range strings.Split(string(bs), "\n") {
+		fields := strings.Split(v, "\t")
+		if i == 0 || len(fields) < 5 {
+			continue
+		}
+		d := gndict.Decision{List: fields[0], Word: fields[1]}
+		switch strings.ToLower(strings.TrimSpace(fields[4])) {
+		case "accept", "y", "yes":
+			d.Accept = true
+		case "reject", "n", "no":
+		case "":
+			continue
+		default:
+			return nil, fmt.Errorf("bad decision on line %d: '%s'",
+				i+1, fields[4])
+		}
+		res = append(res, d)
+	}
+	return res, nil
+}
+
+func saveDecisions(dict gndict.DictGen, ds []gndict.Decision) {
+	err := dict.Decide(ds)
+	if err != nil {
+		err = fmt.Errorf("-> dict.Decide: %w", err)
+		log.Fatal().Err(err).Msg("Cannot save decisions")
+	}
+	log.Info().Msgf("Saved %d decisions", len(ds))
+}
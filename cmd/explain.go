@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gnames/gndict/internal/io/sysio"
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// explainCmd shows how words are classified by grey rules.
+var explainCmd = &cobra.Command{
+	Use:   "explain word [word...]",
+	Short: "Shows how words are classified by grey rules",
+	Long: `Shows how words are classified in uninomials, genera and species
+dictionaries. Every rule is reported with its threshold and result. It uses
+preprocessed data, so gndict should run at least once before.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if scope := scopeFlag(cmd); scope != "" {
+			opts = append(opts, config.OptScope(scope))
+		}
+		cfg := newConfig()
+		sys := sysio.New(cfg)
+		dict := newDict(cfg, nil, sys, nil)
+
+		res, err := dict.Explain(cmd.Context(), args...)
+		if err != nil {
+			err = fmt.Errorf("-> dict.Explain: %w", err)
+			log.Fatal().Err(err).Msg("Cannot explain words")
+		}
+
+		var word string
+		for _, v := range res {
+			if v.Word != word {
+				word = v.Word
+				fmt.Printf("\n%s\n", word)
+			}
+			found := "not found"
+			if v.Found {
+				found = fmt.Sprintf("count %d", v.Record.Count)
+			}
+			fmt.Printf("  %s (%s): %s\n", v.Category, found, v.Record.Bucket)
+			for _, c := range v.Checks {
+				mark := " "
+				if c.Hit {
+					mark = "x"
+				}
+				fmt.Printf("    [%s] %-12s %s\n", mark, c.Rule, c.Detail)
+			}
+		}
+		fmt.Println()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+}
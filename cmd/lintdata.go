@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/gnames/gnsys"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// lintDataCmd checks word lists for problems.
+var lintDataCmd = &cobra.Command{
+	Use:   "lint-data [file...]",
+	Short: "Checks embedded and user-supplied word lists",
+	Long: `Checks embedded word lists and given files for empty lines, blank
+lines, whitespace around words, uppercase letters and duplicates. It also
+checks the PatternsFile and the CurationFile from the config. Exits with
+an error if problems are found.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := newConfig()
+		keepCase, _ := cmd.Flags().GetBool("keep-case")
+
+		issues := data.Lint()
+		for _, v := range args {
+			_, iss, err := data.LoadFile(v, !keepCase)
+			if err != nil {
+				log.Fatal().Err(err).Msgf("Cannot read %s", v)
+			}
+			issues = append(issues, iss...)
+		}
+		for _, v := range issues {
+			fmt.Println(v)
+		}
+
+		errs := lintConfigFiles(cfg)
+		for _, v := range errs {
+			fmt.Println(v)
+		}
+
+		if len(issues)+len(errs) > 0 {
+			log.Error().Msgf("Found %d problems", len(issues)+len(errs))
+			os.Exit(1)
+		}
+		log.Info().Msg("No problems found")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintDataCmd)
+	lintDataCmd.Flags().BoolP("keep-case", "k", false,
+		"Do not report uppercase letters in given files")
+}
+
+// lintConfigFiles checks patterns and curation files set in the config.
+func lintConfigFiles(cfg config.Config) []error {
+	var res []error
+	if cfg.PatternsFile != "" {
+		bs, err := os.ReadFile(cfg.PatternsFile)
+		if err == nil {
+			_, err = data.ParsePatterns(string(bs))
+		}
+		if err != nil {
+			res = append(res, fmt.Errorf("%s: %w", cfg.PatternsFile, err))
+		}
+	}
+
+	if ok, _ := gnsys.FileExists(cfg.CurationFile); ok {
+		_, err := data.ReadCuration(cfg.CurationFile)
+		if err != nil {
+			res = append(res, fmt.Errorf("%s: %w", cfg.CurationFile, err))
+		}
+	}
+	return res
+}
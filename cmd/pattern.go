@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// patternCmd tests a blacklist pattern against the current dictionary.
+var patternCmd = &cobra.Command{
+	Use:   "pattern pattern",
+	Short: "Shows dictionary words that match a blacklist pattern",
+	Long: `Shows words of the current dictionary that match a pattern. Use it to
+test a pattern before adding it to the PatternsFile. The pattern is
+a regular expression, or a glob if it starts with 'glob:'. It is matched
+against lowercase words.
+
+Example:
+  gndict pattern 'glob:*ology'`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if scope := scopeFlag(cmd); scope != "" {
+			opts = append(opts, config.OptScope(scope))
+		}
+		cfg := newConfig()
+		dict := newDict(cfg, nil, nil, nil)
+
+		res, err := dict.MatchPattern(args[0])
+		if err != nil {
+			err = fmt.Errorf("-> dict.MatchPattern: %w", err)
+			log.Fatal().Err(err).Msg("Cannot match pattern")
+		}
+		for _, v := range res {
+			fmt.Printf("%s\t%s\n", v.File, v.Word)
+		}
+		log.Info().Msgf("Found %d matching words", len(res))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(patternCmd)
+}
@@ -22,12 +22,24 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"context"
 	_ "embed"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+	"unicode"
 
+	"github.com/gnames/gndict/internal/ent"
 	"github.com/gnames/gndict/internal/io/downloaderio"
+	"github.com/gnames/gndict/internal/io/sqliteio"
 	"github.com/gnames/gndict/internal/io/sysio"
 	gndict "github.com/gnames/gndict/pkg"
 	"github.com/gnames/gndict/pkg/config"
@@ -48,6 +60,31 @@ type cfgData struct {
 	PgPass   string
 	PgDb     string
 	CacheDir string
+
+	Compression     string
+	DownloadJobs    int
+	DownloadRetries *int
+	KeepBuilds      *int
+	DataSources     []int
+
+	Bloom          *bool
+	SQLite         *bool
+	ASCIIFold      *bool
+	OCR            *bool
+	GenderVariants *bool
+
+	BloomFPRate float64
+	ScoreAmbig  float64
+	ScoreNotIn  float64
+	OCRMinScore float64
+	Scope       string
+	GreyRules   map[string]config.GreyRule
+
+	ShrinkLimits map[string]float64
+
+	PatternsFile string
+	CurationFile string
+	ExtraLists   map[string][]string
 }
 
 // rootCmd represents the base command when called without any subcommands
@@ -56,89 +93,290 @@ var rootCmd = &cobra.Command{
 	Short: "gndict generates dictionaries for GNfinder",
 	Long: `This is a service app, GNfinder uses dictionaries generated from
 the GNverfier data. We use gndict to generate these dictionaries.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return initConfig(cmd)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// errors of the build are logged by logError.
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
 		versionFlag(cmd)
 		if redownloadFlag(cmd) {
 			opts = append(opts, config.OptForceDownload(true))
 		}
-		cfg := config.New(opts...)
+		// output flags override settings, so '--bloom=false' turns off
+		// Bloom filters of a profile.
+		if cmd.Flags().Changed("bloom") {
+			opts = append(opts, config.OptBloom(bloomFlag(cmd)))
+		}
+		if cmd.Flags().Changed("sqlite") {
+			opts = append(opts, config.OptSQLite(sqliteFlag(cmd)))
+		}
+		if cmd.Flags().Changed("ascii") {
+			opts = append(opts, config.OptASCIIFold(asciiFlag(cmd)))
+		}
+		if cmd.Flags().Changed("ocr") {
+			opts = append(opts, config.OptOCR(ocrFlag(cmd)))
+		}
+		if cmd.Flags().Changed("gender") {
+			opts = append(opts, config.OptGenderVariants(genderFlag(cmd)))
+		}
+		if scope := scopeFlag(cmd); scope != "" {
+			opts = append(opts, config.OptScope(scope))
+		}
+		if jobs := jobsFlag(cmd); jobs > 0 {
+			opts = append(opts, config.OptDownloadJobs(jobs))
+		}
+		if cmd.Flags().Changed("keep") {
+			opts = append(opts, config.OptKeepBuilds(keepFlag(cmd)))
+		}
+		cfg := newConfig()
 
-		dl := downloaderio.New(cfg)
+		ctx := cmd.Context()
+		if timeout := timeoutFlag(cmd); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		dl, err := downloaderio.New(ctx, cfg)
+		if err != nil {
+			err = fmt.Errorf("-> downloaderio.New: %w", err)
+			return logError(err, "Cannot connect to the database")
+		}
 		defer dl.Close()
 
 		sys := sysio.New(cfg)
 
-		dict := gndict.New(cfg, dl, sys)
+		var st ent.Store
+		if cfg.SQLite {
+			st = sqliteio.New(cfg)
+			defer st.Close()
+		}
 
-		_ = dict
-		err := dict.Download()
+		dict := newDict(cfg, dl, sys, st)
+
+		err = dict.Download(ctx)
 		if err != nil {
 			err = fmt.Errorf("-> dict.Download: %w", err)
-			log.Fatal().Err(err).Msg("Cannot download names")
+			return logError(err, "Cannot download names")
 		}
 
-		err = dict.Preprocess()
+		err = dict.Preprocess(ctx)
 		if err != nil {
 			err = fmt.Errorf("-> dict.Preprocess: %w", err)
-			log.Fatal().Err(err).Msg("Cannot Preprocess")
+			return logError(err, "Cannot Preprocess")
 		}
 
-		if err == nil {
-			err = dict.Output()
-			if err != nil {
-				err = fmt.Errorf("-> dict.Output: %w", err)
-				log.Fatal().Err(err).Msg("Cannot build output")
-			}
+		err = dict.Output(ctx)
+		// the summary helps to understand why the report check failed.
+		printSummary(os.Stderr, dict.Summary())
+		if err != nil {
+			err = fmt.Errorf("-> dict.Output: %w", err)
+			return logError(err, "Cannot build output")
 		}
-
+		return nil
 	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// SIGINT and SIGTERM cancel the context of commands.
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(
+		context.Background(), os.Interrupt, syscall.SIGTERM,
+	)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
 	if err != nil {
 		os.Exit(1)
 	}
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
 	rootCmd.Flags().BoolP("version", "V", false, "Show version")
 	rootCmd.Flags().BoolP("redownload", "r", false, "Force reload from db")
+	rootCmd.Flags().BoolP("bloom", "b", false,
+		"Create Bloom filter files beside dictionary files")
+	rootCmd.Flags().BoolP("sqlite", "s", false,
+		"Also save dictionary to a SQLite database")
+	rootCmd.Flags().BoolP("ascii", "a", false,
+		"Create ASCII variants of words with diacritics")
+	rootCmd.Flags().BoolP("ocr", "o", false,
+		"Create OCR-error variants of genera and species")
+	rootCmd.Flags().BoolP("gender", "g", false,
+		"Create gender-agreement variants of specific epithets")
+	rootCmd.Flags().IntP("jobs", "j", 0,
+		"Number of parallel queries for names download")
+	rootCmd.Flags().IntP("keep", "k", 0,
+		"Number of the newest builds to keep, 0 keeps all")
+	rootCmd.Flags().Duration("timeout", 0,
+		"Stop the build after a time limit (e.g. 90m, 2h)")
+	rootCmd.PersistentFlags().StringP("scope", "t", "",
+		"Build dictionary only for a taxon (e.g. Plantae, Aves)")
+
+	rootCmd.PersistentFlags().String("config", "",
+		"Path to a config file instead of ~/.config/gndict.yaml")
+	rootCmd.PersistentFlags().String("profile", "",
+		"Name of a profile from the Profiles section of the config file")
+	_ = viper.BindPFlag("Profile", rootCmd.PersistentFlags().Lookup("profile"))
+	_ = viper.BindEnv("Profile", envPrefix+"PROFILE")
+	pf := rootCmd.PersistentFlags()
+	pf.String("cache-dir", "", "Directory for downloaded files and dictionaries")
+	pf.String("pg-host", "", "Host of the GNverifier database")
+	pf.String("pg-user", "", "User of the GNverifier database")
+	pf.String("pg-pass", "", "Password of the GNverifier database")
+	pf.String("pg-db", "", "Name of the GNverifier database")
+	for k, v := range settingFlags {
+		_ = viper.BindPFlag(k, pf.Lookup(v))
+	}
 }
 
-// initConfig reads in config file and ENV variables if set.
-func initConfig() {
-	configFile := "gndict"
-	cfgPath, err := os.UserHomeDir()
-	if err != nil {
-		log.Fatal().Err(err).Msg("Cannot find home directory")
+// settingFlags are flags that override settings of the config file.
+var settingFlags = map[string]string{
+	"CacheDir": "cache-dir",
+	"PgHost":   "pg-host",
+	"PgUser":   "pg-user",
+	"PgPass":   "pg-pass",
+	"PgDb":     "pg-db",
+}
+
+// envPrefix is the prefix of environment variables with settings.
+const envPrefix = "GNDICT_"
+
+// bindEnv binds settings to environment variables, for example CacheDir
+// to GNDICT_CACHE_DIR. Settings that are maps can only be set in the config
+// file.
+func bindEnv() {
+	t := reflect.TypeOf(cfgData{})
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.Type.Kind() == reflect.Map {
+			continue
+		}
+		_ = viper.BindEnv(f.Name, envName(f.Name))
 	}
-	cfgPath = filepath.Join(cfgPath, ".config")
+}
+
+// envWords are words of setting names that envName would split.
+var envWords = strings.NewReplacer("SQLite", "Sqlite")
 
-	// Search config in home directory with name ".gnames" (without extension).
-	viper.AddConfigPath(cfgPath)
-	viper.SetConfigName(configFile)
+// envName converts a setting name to the name of its environment variable,
+// for example BloomFPRate to GNDICT_BLOOM_FP_RATE.
+func envName(key string) string {
+	rs := []rune(envWords.Replace(key))
+	var b strings.Builder
+	b.WriteString(envPrefix)
+	for i, r := range rs {
+		if i > 0 && unicode.IsUpper(r) &&
+			(!unicode.IsUpper(rs[i-1]) ||
+				(i+1 < len(rs) && unicode.IsLower(rs[i+1]))) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
 
-	configPath := filepath.Join(cfgPath, fmt.Sprintf("%s.yaml", configFile))
-	touchConfigFile(configPath)
+// initConfig reads in config file and ENV variables if set. Settings are
+// taken from flags first, then from environment variables, then from the
+// config file.
+func initConfig(cmd *cobra.Command) error {
+	bindEnv()
 
-	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
+	if path, _ := cmd.Flags().GetString("config"); path != "" {
+		viper.SetConfigFile(path)
+		if err := viper.ReadInConfig(); err != nil {
+			return fmt.Errorf("cannot read config file %s: %w", path, err)
+		}
 		log.Info().Msgf("Using config file: %s.", viper.ConfigFileUsed())
+		return useProfile()
 	}
-	getOpts()
+
+	configPath, err := defaultConfigPath()
+	if err != nil {
+		return err
+	}
+	viper.SetConfigFile(configPath)
+
+	// If a config file is found, read it in, otherwise defaults are used.
+	err = viper.ReadInConfig()
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		log.Info().Msgf(
+			"Config file %s is not found, create it with 'gndict config init'",
+			configPath,
+		)
+	case err != nil:
+		return fmt.Errorf("cannot read config file %s: %w", configPath, err)
+	default:
+		log.Info().Msgf("Using config file: %s.", viper.ConfigFileUsed())
+	}
+	return useProfile()
 }
 
-func getOpts() []config.Option {
+// profile is the name of the used profile.
+var profile string
+
+// profileSettings are settings of the used profile.
+var profileSettings map[string]any
+
+// useProfile applies settings of the selected profile on top of other
+// settings of the config file and reads options.
+func useProfile() error {
+	profile = viper.GetString("Profile")
+	if profile == "" {
+		return getOpts()
+	}
+	if strings.Contains(profile, ".") {
+		return fmt.Errorf("profile name '%s' cannot contain '.'", profile)
+	}
+	sub := viper.Sub("Profiles." + profile)
+	if sub == nil {
+		return fmt.Errorf("profile '%s' is not found in the config file", profile)
+	}
+	profileSettings = sub.AllSettings()
+
+	err := viper.MergeConfigMap(profileSettings)
+	if err != nil {
+		return fmt.Errorf("cannot use profile '%s': %w", profile, err)
+	}
+	log.Info().Msgf("Using profile: %s.", profile)
+	return getOpts()
+}
+
+// profileCacheDir tells if the CacheDir in use comes from the profile.
+func profileCacheDir() bool {
+	dir, ok := profileSettings["cachedir"].(string)
+	return ok && dir == viper.GetString("CacheDir")
+}
+
+// defaultConfigPath returns the path to ~/.config/gndict.yaml.
+func defaultConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "gndict.yaml"), nil
+}
+
+func getOpts() error {
 	cfg := &cfgData{}
 	err := viper.Unmarshal(cfg)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Cannot deserialize config data")
+		return fmt.Errorf("cannot deserialize config data: %w", err)
 	}
 
+	// profiles do not share builds and reports, unless the profile sets
+	// its own CacheDir. The profile directory is added to the CacheDir that
+	// is left after flags and environment variables override the file.
+	if profile != "" && !profileCacheDir() {
+		dir := cfg.CacheDir
+		if dir == "" {
+			dir = config.New().CacheDir
+		}
+		cfg.CacheDir = filepath.Join(dir, "profiles", profile)
+	}
 	if cfg.CacheDir != "" {
 		opts = append(opts, config.OptCacheDir(cfg.CacheDir))
 	}
@@ -154,7 +392,93 @@ func getOpts() []config.Option {
 	if cfg.PgDb != "" {
 		opts = append(opts, config.OptPgDb(cfg.PgDb))
 	}
-	return opts
+	if cfg.Compression != "" {
+		opts = append(opts, config.OptCompression(cfg.Compression))
+	}
+	if cfg.DownloadJobs > 0 {
+		opts = append(opts, config.OptDownloadJobs(cfg.DownloadJobs))
+	}
+	if cfg.DownloadRetries != nil {
+		opts = append(opts, config.OptDownloadRetries(*cfg.DownloadRetries))
+	}
+	if cfg.KeepBuilds != nil {
+		opts = append(opts, config.OptKeepBuilds(*cfg.KeepBuilds))
+	}
+	if len(cfg.DataSources) > 0 {
+		opts = append(opts, config.OptDataSources(cfg.DataSources))
+	}
+	if cfg.Bloom != nil {
+		opts = append(opts, config.OptBloom(*cfg.Bloom))
+	}
+	if cfg.SQLite != nil {
+		opts = append(opts, config.OptSQLite(*cfg.SQLite))
+	}
+	if cfg.ASCIIFold != nil {
+		opts = append(opts, config.OptASCIIFold(*cfg.ASCIIFold))
+	}
+	if cfg.OCR != nil {
+		opts = append(opts, config.OptOCR(*cfg.OCR))
+	}
+	if cfg.GenderVariants != nil {
+		opts = append(opts, config.OptGenderVariants(*cfg.GenderVariants))
+	}
+	if cfg.BloomFPRate > 0 {
+		opts = append(opts, config.OptBloomFPRate(cfg.BloomFPRate))
+	}
+	if cfg.ScoreAmbig > 0 {
+		opts = append(opts, config.OptScoreAmbig(cfg.ScoreAmbig))
+	}
+	if cfg.ScoreNotIn > 0 {
+		opts = append(opts, config.OptScoreNotIn(cfg.ScoreNotIn))
+	}
+	if cfg.OCRMinScore > 0 {
+		opts = append(opts, config.OptOCRMinScore(cfg.OCRMinScore))
+	}
+	if cfg.Scope != "" {
+		opts = append(opts, config.OptScope(cfg.Scope))
+	}
+	if cfg.PatternsFile != "" {
+		opts = append(opts, config.OptPatternsFile(cfg.PatternsFile))
+	}
+	if cfg.CurationFile != "" {
+		opts = append(opts, config.OptCurationFile(cfg.CurationFile))
+	}
+	if len(cfg.GreyRules) > 0 {
+		opts = append(opts, config.OptGreyRules(cfg.GreyRules))
+	}
+	if len(cfg.ShrinkLimits) > 0 {
+		opts = append(opts, config.OptShrinkLimits(cfg.ShrinkLimits))
+	}
+	if len(cfg.ExtraLists) > 0 {
+		opts = append(opts, config.OptExtraLists(cfg.ExtraLists))
+	}
+	return nil
+}
+
+// newConfig creates configuration from the config file and flags and
+// exits if the configuration is invalid.
+func newConfig() config.Config {
+	cfg := config.New(opts...)
+	err := cfg.Validate()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid configuration")
+	}
+	return cfg
+}
+
+// newDict creates DictGen and exits if it cannot be created.
+func newDict(
+	cfg config.Config,
+	dl ent.Downloader,
+	sys ent.Sys,
+	st ent.Store,
+) gndict.DictGen {
+	res, err := gndict.New(cfg, dl, sys, st)
+	if err != nil {
+		err = fmt.Errorf("-> gndict.New: %w", err)
+		log.Fatal().Err(err).Msg("Cannot read curation decisions or word lists")
+	}
+	return res
 }
 
 func versionFlag(cmd *cobra.Command) {
@@ -171,25 +495,94 @@ func redownloadFlag(cmd *cobra.Command) bool {
 	return b
 }
 
-// touchConfigFile checks if config file exists, and if not, it gets created.
-func touchConfigFile(configPath string) {
-	if ok, err := gnsys.FileExists(configPath); ok && err == nil {
-		return
+func bloomFlag(cmd *cobra.Command) bool {
+	b, _ := cmd.Flags().GetBool("bloom")
+	return b
+}
+
+func sqliteFlag(cmd *cobra.Command) bool {
+	b, _ := cmd.Flags().GetBool("sqlite")
+	return b
+}
+
+func asciiFlag(cmd *cobra.Command) bool {
+	b, _ := cmd.Flags().GetBool("ascii")
+	return b
+}
+
+func ocrFlag(cmd *cobra.Command) bool {
+	b, _ := cmd.Flags().GetBool("ocr")
+	return b
+}
+
+func genderFlag(cmd *cobra.Command) bool {
+	b, _ := cmd.Flags().GetBool("gender")
+	return b
+}
+
+func scopeFlag(cmd *cobra.Command) string {
+	s, _ := cmd.Flags().GetString("scope")
+	return s
+}
+
+func jobsFlag(cmd *cobra.Command) int {
+	i, _ := cmd.Flags().GetInt("jobs")
+	return i
+}
+
+func keepFlag(cmd *cobra.Command) int {
+	i, _ := cmd.Flags().GetInt("keep")
+	return i
+}
+
+func timeoutFlag(cmd *cobra.Command) time.Duration {
+	d, _ := cmd.Flags().GetDuration("timeout")
+	return d
+}
+
+// printSummary prints a table with durations of stages and a table with
+// the number of entries in every dictionary file.
+func printSummary(w io.Writer, sum gndict.Summary) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nStage\tRows\tDuration")
+	for _, v := range sum.Timings {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n",
+			v.Stage, v.Rows, v.Duration.Round(time.Millisecond))
 	}
+	if len(sum.Files) > 0 {
+		fmt.Fprintln(tw, "\nFile\tEntries\t")
+		for _, v := range sum.Files {
+			fmt.Fprintf(tw, "%s\t%d\t\n", v, sum.Entries[v])
+		}
+	}
+	tw.Flush()
+}
 
-	log.Info().Msgf("Creating config file '%s'", configPath)
-	createConfig(configPath)
+// logError logs the error and returns it, so the command exits after
+// deferred cleanup is done. Interrupted and timed out builds get their own
+// messages.
+func logError(err error, msg string) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		log.Error().Err(err).Msg("Interrupted, partial files are removed")
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Error().Err(err).Msg("Timeout, partial files are removed")
+	default:
+		log.Error().Err(err).Msg(msg)
+	}
+	return err
 }
 
 // createConfig creates config file.
-func createConfig(path string) {
+func createConfig(path string) error {
 	err := gnsys.MakeDir(filepath.Dir(path))
 	if err != nil {
-		log.Fatal().Err(err).Msgf("Cannot create dir %s", path)
+		return fmt.Errorf("cannot create dir %s: %w", filepath.Dir(path), err)
 	}
 
 	err = os.WriteFile(path, []byte(configYAML), 0644)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("Cannot write to file %s", path)
+		return fmt.Errorf("cannot write to file %s: %w", path, err)
 	}
+	return nil
 }
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvName(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		msg, key, res string
+	}{
+		{"one word", "Scope", "GNDICT_SCOPE"},
+		{"two words", "CacheDir", "GNDICT_CACHE_DIR"},
+		{"short words", "PgDb", "GNDICT_PG_DB"},
+		{"acronym inside", "BloomFPRate", "GNDICT_BLOOM_FP_RATE"},
+		{"acronym first", "OCRMinScore", "GNDICT_OCR_MIN_SCORE"},
+		{"acronym last", "ASCIIFold", "GNDICT_ASCII_FOLD"},
+		{"only acronym", "OCR", "GNDICT_OCR"},
+		{"one word with capitals", "SQLite", "GNDICT_SQLITE"},
+		{"lowercase", "profile", "GNDICT_PROFILE"},
+	}
+	for _, v := range tests {
+		assert.Equal(v.res, envName(v.key), v.msg)
+	}
+}
@@ -0,0 +1,166 @@
+// Package compress reads and writes cache files that might be compressed.
+// The compression method of a file is recognized by its extension.
+package compress
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression methods.
+const (
+	None = "none"
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+// Methods are supported compression methods.
+var Methods = []string{None, Gzip, Zstd}
+
+// exts are file extensions of compression methods.
+var exts = map[string]string{
+	None: "",
+	Gzip: ".gz",
+	Zstd: ".zst",
+}
+
+// bufSize is the size of buffers for reading and writing files.
+const bufSize = 1 << 20
+
+// Path returns the path of a file compressed with the method.
+func Path(path, method string) string {
+	return path + exts[method]
+}
+
+// Find returns the path of an existing file with any of the supported
+// compression extensions. It returns false if the file does not exist.
+func Find(path string) (string, bool) {
+	for _, v := range Methods {
+		res := Path(path, v)
+		if _, err := os.Stat(res); err == nil {
+			return res, true
+		}
+	}
+	return "", false
+}
+
+// Remove deletes all compressed and uncompressed variants of a file.
+func Remove(path string) error {
+	var errs []error
+	for _, v := range Methods {
+		err := os.Remove(Path(path, v))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Create creates a file compressed with the method. The extension of the
+// method is added to the path, other variants of the file are removed.
+// Close has to be called to flush buffered data.
+func Create(path, method string) (io.WriteCloser, error) {
+	ext, ok := exts[method]
+	if !ok {
+		return nil, fmt.Errorf("unknown compression method '%s'", method)
+	}
+	err := Remove(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path + ext)
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewWriterSize(f, bufSize)
+	res := &writer{Writer: buf, buf: buf, f: f}
+
+	switch method {
+	case Gzip:
+		res.enc = gzip.NewWriter(buf)
+	case Zstd:
+		res.enc, err = zstd.NewWriter(buf)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if res.enc != nil {
+		res.Writer = res.enc
+	}
+	return res, nil
+}
+
+// Open opens a file that might be compressed. The file is found by Find.
+// If there is no such file, the error wraps os.ErrNotExist.
+func Open(path string) (io.ReadCloser, error) {
+	found, ok := Find(path)
+	if !ok {
+		return nil, fmt.Errorf("open %s: %w", path, os.ErrNotExist)
+	}
+	f, err := os.Open(found)
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewReaderSize(f, bufSize)
+
+	switch found {
+	case Path(path, Gzip):
+		r, err := gzip.NewReader(buf)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &reader{Reader: r, closers: []io.Closer{r, f}}, nil
+	case Path(path, Zstd):
+		r, err := zstd.NewReader(buf)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &reader{Reader: r, closers: []io.Closer{r.IOReadCloser(), f}}, nil
+	}
+	return &reader{Reader: buf, closers: []io.Closer{f}}, nil
+}
+
+type writer struct {
+	io.Writer
+	enc    io.WriteCloser
+	buf    *bufio.Writer
+	f      *os.File
+	closed bool
+}
+
+// Close flushes compressed data and closes the file. It is safe to call
+// Close more than once.
+func (w *writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	var errs []error
+	if w.enc != nil {
+		errs = append(errs, w.enc.Close())
+	}
+	errs = append(errs, w.buf.Flush(), w.f.Close())
+	return errors.Join(errs...)
+}
+
+type reader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *reader) Close() error {
+	var errs []error
+	for _, v := range r.closers {
+		errs = append(errs, v.Close())
+	}
+	return errors.Join(errs...)
+}
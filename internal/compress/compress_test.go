@@ -0,0 +1,92 @@
+package compress_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gnames/gndict/internal/compress"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	text := "Aus bus,3\nPoa annua,5\n"
+	tests := []struct {
+		msg, method, file string
+	}{
+		{"none", compress.None, "names.txt"},
+		{"gzip", compress.Gzip, "names.txt.gz"},
+		{"zstd", compress.Zstd, "names.txt.zst"},
+	}
+
+	for _, v := range tests {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "names.txt")
+		w, err := compress.Create(path, v.method)
+		require.Nil(err, v.msg)
+		_, err = io.WriteString(w, text)
+		require.Nil(err, v.msg)
+		require.Nil(w.Close(), v.msg)
+		// second Close does nothing.
+		assert.Nil(w.Close(), v.msg)
+
+		found, ok := compress.Find(path)
+		assert.True(ok, v.msg)
+		assert.Equal(filepath.Join(dir, v.file), found, v.msg)
+		assert.Equal(found, compress.Path(path, v.method), v.msg)
+
+		r, err := compress.Open(path)
+		require.Nil(err, v.msg)
+		res, err := io.ReadAll(r)
+		require.Nil(err, v.msg)
+		require.Nil(r.Close(), v.msg)
+		assert.Equal(text, string(res), v.msg)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	path := filepath.Join(t.TempDir(), "names.txt")
+
+	_, err := compress.Create(path, "lzma")
+	assert.NotNil(err)
+
+	w, err := compress.Create(path, compress.Gzip)
+	require.Nil(err)
+	require.Nil(w.Close())
+
+	// other variants of the file are removed.
+	w, err = compress.Create(path, compress.Zstd)
+	require.Nil(err)
+	require.Nil(w.Close())
+	_, err = os.Stat(compress.Path(path, compress.Gzip))
+	assert.ErrorIs(err, os.ErrNotExist)
+	found, _ := compress.Find(path)
+	assert.Equal(compress.Path(path, compress.Zstd), found)
+}
+
+func TestRemove(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	path := filepath.Join(t.TempDir(), "names.txt")
+
+	// missing files are not an error.
+	assert.Nil(compress.Remove(path))
+
+	for _, v := range compress.Methods {
+		f, err := os.Create(compress.Path(path, v))
+		require.Nil(err)
+		require.Nil(f.Close())
+	}
+	assert.Nil(compress.Remove(path))
+	_, ok := compress.Find(path)
+	assert.False(ok)
+
+	_, err := compress.Open(path)
+	assert.ErrorIs(err, os.ErrNotExist)
+}
@@ -0,0 +1,260 @@
+package ent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/gnames/gnsys"
+	"github.com/rs/zerolog/log"
+)
+
+// currentLink is the name of the symbolic link to the current build in
+// the BuildsDir.
+const currentLink = "current"
+
+// activatedFile marks builds that were current at some time. Builds without
+// it failed checks after dictionaries were created.
+const activatedFile = ".activated"
+
+// buildIDLayout parses default build IDs. Fractional seconds are accepted
+// after the seconds field, so it parses both config.BuildIDFormat and
+// IDs without milliseconds made by older versions.
+const buildIDLayout = "20060102-150405"
+
+// Build is a generation of dictionaries kept in the BuildsDir.
+type Build struct {
+	// ID is the name of the build directory.
+	ID string
+
+	// Path is the path to the build directory.
+	Path string
+
+	// Created is the time when the build was made.
+	Created time.Time
+
+	// Current is true for the build that is used as the dictionary.
+	Current bool
+
+	// Activated is true if the build was current at some time.
+	Activated bool
+}
+
+// Builds returns all builds sorted from the oldest to the newest.
+func Builds(cfg config.Config) ([]Build, error) {
+	dir := cfg.BuildsDir()
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	current, _ := os.Readlink(filepath.Join(dir, currentLink))
+
+	var res []Build
+	for _, v := range entries {
+		if !v.IsDir() || v.Name()[0] == '.' {
+			continue
+		}
+		// default IDs keep the time of the build, modification time of
+		// the directory changes when the build is activated.
+		created, err := time.Parse(buildIDLayout, v.Name())
+		if err != nil {
+			info, err := v.Info()
+			if err != nil {
+				return nil, err
+			}
+			created = info.ModTime()
+		}
+		path := filepath.Join(dir, v.Name())
+		activated, _ := gnsys.FileExists(filepath.Join(path, activatedFile))
+		res = append(res, Build{
+			ID:        v.Name(),
+			Path:      path,
+			Created:   created,
+			Current:   v.Name() == current,
+			Activated: activated,
+		})
+	}
+	slices.SortFunc(res, func(a, b Build) int {
+		return a.Created.Compare(b.Created)
+	})
+	return res, nil
+}
+
+// Activate makes a build current. The report of the build becomes the one
+// new builds are compared to.
+func Activate(cfg config.Config, id string) error {
+	dir := filepath.Join(cfg.BuildsDir(), id)
+	if ok, _, _ := gnsys.DirExists(dir); !ok {
+		return fmt.Errorf("build '%s' does not exist", id)
+	}
+	err := migrateDict(cfg)
+	if err != nil {
+		return fmt.Errorf("-> migrateDict: %w", err)
+	}
+	err = os.WriteFile(filepath.Join(dir, activatedFile), nil, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = linkBuild(cfg, id)
+	if err != nil {
+		return err
+	}
+
+	rep, err := os.ReadFile(filepath.Join(dir, reportFile+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(cfg.WorkDir(), reportFile+".json")
+	return os.WriteFile(path, rep, 0644)
+}
+
+// Rollback makes a build current. If id is empty, the newest build made
+// before the current one that was current before is used. It returns
+// the ID of the new current build.
+func Rollback(cfg config.Config, id string) (string, error) {
+	if id == "" {
+		bs, err := Builds(cfg)
+		if err != nil {
+			return "", err
+		}
+		idx := slices.IndexFunc(bs, func(b Build) bool { return b.Current })
+		for i := idx - 1; i >= 0; i-- {
+			if bs[i].Activated {
+				id = bs[i].ID
+				break
+			}
+		}
+		if id == "" {
+			return "", errors.New("there is no build before the current one")
+		}
+	}
+	return id, Activate(cfg, id)
+}
+
+// Prune removes builds except the keep newest ones and the current one.
+// It returns IDs of removed builds.
+func Prune(cfg config.Config, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	bs, err := Builds(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for i := 0; i < len(bs)-keep; i++ {
+		if bs[i].Current {
+			continue
+		}
+		err = os.RemoveAll(bs[i].Path)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, bs[i].ID)
+	}
+	return res, nil
+}
+
+// migrateDict moves a dictionary directory made before builds were
+// introduced to the BuildsDir, so it is kept as one of the builds.
+// Dictionaries of scopes were kept in subdirectories of the main
+// dictionary, they are moved to the builds of their scopes.
+func migrateDict(cfg config.Config) error {
+	cfg.Scope = ""
+	info, err := os.Lstat(cfg.DictDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(cfg.DictDir())
+	if err != nil {
+		return err
+	}
+	for _, v := range entries {
+		scfg := cfg
+		scfg.Scope = v.Name()
+		if !v.IsDir() || scfg.ScopeDir() != v.Name() {
+			continue
+		}
+		if ok, _, _ := gnsys.DirExists(scfg.WorkDir()); !ok {
+			continue
+		}
+		err = moveDict(scfg, filepath.Join(cfg.DictDir(), v.Name()))
+		if err != nil {
+			return err
+		}
+	}
+	return moveDict(cfg, cfg.DictDir())
+}
+
+// moveDict moves a dictionary directory to the BuildsDir as an activated
+// build. If there is no current build, the moved one becomes current.
+func moveDict(cfg config.Config, dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	id := info.ModTime().UTC().Format(config.BuildIDFormat)
+	path := filepath.Join(cfg.BuildsDir(), id)
+	err = gnsys.MakeDir(cfg.BuildsDir())
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("Moving %s to %s", dir, path)
+	err = os.Rename(dir, path)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(path, activatedFile), nil, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = os.Lstat(cfg.DictDir()); err == nil {
+		return nil
+	}
+	return linkBuild(cfg, id)
+}
+
+// linkBuild points the current link of the BuildsDir to the build and
+// the DictDir to the current link.
+func linkBuild(cfg config.Config, id string) error {
+	err := symlink(id, filepath.Join(cfg.BuildsDir(), currentLink))
+	if err != nil {
+		return err
+	}
+	return symlink(
+		filepath.Join(filepath.Base(cfg.BuildsDir()), currentLink),
+		cfg.DictDir(),
+	)
+}
+
+// symlink atomically creates or replaces a symbolic link.
+func symlink(target, path string) error {
+	tmp := path + ".tmp"
+	err := os.Remove(tmp)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	err = os.Symlink(target, tmp)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,153 @@
+package ent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeBuilds creates empty builds with the IDs and activates them one
+// after another.
+func makeBuilds(t *testing.T, cfg config.Config, ids ...string) {
+	for _, v := range ids {
+		err := os.MkdirAll(filepath.Join(cfg.BuildsDir(), v), 0755)
+		require.Nil(t, err)
+		require.Nil(t, Activate(cfg, v))
+	}
+}
+
+func buildIDs(t *testing.T, cfg config.Config) ([]string, string) {
+	bs, err := Builds(cfg)
+	require.Nil(t, err)
+	var res []string
+	var current string
+	for _, v := range bs {
+		res = append(res, v.ID)
+		if v.Current {
+			current = v.ID
+		}
+	}
+	return res, current
+}
+
+func TestBuilds(t *testing.T) {
+	assert := assert.New(t)
+	cfg := config.New(config.OptCacheDir(t.TempDir()))
+	bs, err := Builds(cfg)
+	assert.Nil(err)
+	assert.Empty(bs)
+
+	// IDs without milliseconds from older versions are sorted by time too.
+	makeBuilds(t, cfg,
+		"20261019-143005.900", "20261019-143005", "20261019-143005.042",
+	)
+	ids, current := buildIDs(t, cfg)
+	assert.Equal([]string{
+		"20261019-143005", "20261019-143005.042", "20261019-143005.900",
+	}, ids)
+	assert.Equal("20261019-143005.042", current)
+
+	// the dict link leads to the current build.
+	path, err := filepath.EvalSymlinks(cfg.DictDir())
+	assert.Nil(err)
+	assert.Equal("20261019-143005.042", filepath.Base(path))
+
+	err = Activate(cfg, "20261019-000000")
+	assert.NotNil(err)
+}
+
+func TestRollback(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	cfg := config.New(config.OptCacheDir(t.TempDir()))
+	makeBuilds(t, cfg, "20261016-100000.000", "20261017-100000.000")
+	// a build that failed checks was never activated.
+	failed := filepath.Join(cfg.BuildsDir(), "20261018-100000.000")
+	err := os.MkdirAll(failed, 0755)
+	require.Nil(err)
+	makeBuilds(t, cfg, "20261019-100000.000")
+
+	id, err := Rollback(cfg, "")
+	require.Nil(err)
+	assert.Equal("20261017-100000.000", id)
+
+	id, err = Rollback(cfg, "")
+	require.Nil(err)
+	assert.Equal("20261016-100000.000", id)
+	_, current := buildIDs(t, cfg)
+	assert.Equal("20261016-100000.000", current)
+
+	_, err = Rollback(cfg, "")
+	assert.NotNil(err)
+
+	// any build can be made current by its ID.
+	id, err = Rollback(cfg, "20261018-100000.000")
+	require.Nil(err)
+	assert.Equal("20261018-100000.000", id)
+	_, current = buildIDs(t, cfg)
+	assert.Equal("20261018-100000.000", current)
+}
+
+func TestPrune(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	cfg := config.New(config.OptCacheDir(t.TempDir()))
+	makeBuilds(t, cfg,
+		"20261016-100000.000", "20261017-100000.000",
+		"20261018-100000.000", "20261019-100000.000",
+	)
+	_, err := Rollback(cfg, "20261016-100000.000")
+	require.Nil(err)
+
+	res, err := Prune(cfg, 0)
+	assert.Nil(err)
+	assert.Empty(res)
+
+	// the current build is kept even if it is old.
+	res, err = Prune(cfg, 1)
+	require.Nil(err)
+	assert.Equal([]string{"20261017-100000.000", "20261018-100000.000"}, res)
+	ids, current := buildIDs(t, cfg)
+	assert.Equal([]string{"20261016-100000.000", "20261019-100000.000"}, ids)
+	assert.Equal("20261016-100000.000", current)
+}
+
+func TestMigrateDict(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	cfg := config.New(config.OptCacheDir(t.TempDir()))
+	scfg := config.New(
+		config.OptCacheDir(cfg.CacheDir), config.OptScope("Plantae"),
+	)
+
+	// dictionaries of older versions with a dictionary of the scope inside.
+	files := []string{
+		filepath.Join(cfg.DictDir(), "in", "genera.csv"),
+		filepath.Join(cfg.DictDir(), "plantae", "in", "genera.csv"),
+	}
+	for _, v := range files {
+		require.Nil(os.MkdirAll(filepath.Dir(v), 0755))
+		require.Nil(os.WriteFile(v, nil, 0644))
+	}
+	require.Nil(os.MkdirAll(scfg.WorkDir(), 0755))
+
+	makeBuilds(t, cfg, "20261019-100000.000")
+	ids, current := buildIDs(t, cfg)
+	assert.Len(ids, 2)
+	assert.Equal("20261019-100000.000", current)
+	_, err := os.Stat(filepath.Join(cfg.BuildsDir(), ids[0], "in", "genera.csv"))
+	assert.Nil(err)
+	_, err = os.Stat(filepath.Join(cfg.BuildsDir(), ids[0], "plantae"))
+	assert.ErrorIs(err, os.ErrNotExist)
+
+	// the dictionary of the scope becomes the current build of the scope.
+	ids, current = buildIDs(t, scfg)
+	assert.Len(ids, 1)
+	assert.Equal(ids[0], current)
+	_, err = os.Stat(filepath.Join(scfg.DictDir(), "in", "genera.csv"))
+	assert.Nil(err)
+}
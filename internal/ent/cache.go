@@ -0,0 +1,252 @@
+package ent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gnames/gndict/internal/compress"
+	"github.com/gnames/gndict/pkg/config"
+)
+
+// Stages of the pipeline that leave files in the cache.
+const (
+	StageDownload   = "download"
+	StagePreprocess = "preprocess"
+	StageOutput     = "output"
+)
+
+// Stages are all stages of the pipeline in the order they run.
+var Stages = []string{StageDownload, StagePreprocess, StageOutput}
+
+// downloadFiles are the files created by Download.
+var downloadFiles = []string{"names.txt", "genera.txt", "kingdoms.txt"}
+
+// CacheFile describes a file or a directory of the cache created by one of
+// the stages.
+type CacheFile struct {
+	// Stage is the stage of the pipeline that creates the file.
+	Stage string
+
+	// Name is the path of the file relative to the WorkDir without
+	// compression extension.
+	Name string
+
+	// Path is the path to the existing file with compression extension.
+	// It is empty if the file does not exist.
+	Path string
+
+	// Size is the size of the file in bytes. For directories it is the size
+	// of all files in the directory.
+	Size int64
+
+	// Lines is the number of lines in the file. For directories it is the
+	// number of entries in all CSV files of the directory.
+	Lines int
+
+	// Modified is the time of the last change of the file.
+	Modified time.Time
+}
+
+// Exists returns true if the file is in the cache.
+func (cf CacheFile) Exists() bool {
+	return cf.Path != ""
+}
+
+// CacheStatus returns files of all stages of the pipeline in the WorkDir
+// in the order they are created.
+func CacheStatus(ctx context.Context, cfg config.Config) ([]CacheFile, error) {
+	var res []CacheFile
+	for _, v := range downloadFiles {
+		cf, err := cacheFile(ctx, cfg, StageDownload, v)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, cf)
+	}
+	for _, v := range preprocFiles {
+		cf, err := cacheFile(ctx, cfg, StagePreprocess, v)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, cf)
+	}
+
+	cf, err := dictStatus(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	res = append(res, cf)
+	return res, nil
+}
+
+// CleanCache removes files of the given stages from the WorkDir. If no
+// stages are given, files of all stages are removed. Files that are not
+// created by the pipeline, like the CurationFile, are never removed. It
+// returns paths of removed files.
+func CleanCache(cfg config.Config, stages ...string) ([]string, error) {
+	if len(stages) == 0 {
+		stages = Stages
+	}
+	for _, v := range stages {
+		if !slices.Contains(Stages, v) {
+			return nil, fmt.Errorf("unknown stage '%s', use one of %s",
+				v, strings.Join(Stages, ", "))
+		}
+	}
+
+	var paths []string
+	dir := cfg.WorkDir()
+	for _, v := range stages {
+		switch v {
+		case StageDownload:
+			for _, f := range downloadFiles {
+				paths = append(paths, variants(filepath.Join(dir, f))...)
+			}
+			// parts of the names dump are left if download was killed.
+			parts, err := filepath.Glob(filepath.Join(dir, "names.txt.part*"))
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, parts...)
+		case StagePreprocess:
+			for _, f := range preprocFiles {
+				paths = append(paths, variants(filepath.Join(dir, f))...)
+			}
+		case StageOutput:
+			paths = append(paths,
+				cfg.DictDir(),
+				cfg.BuildsDir(),
+				filepath.Join(dir, reportFile+".json"),
+			)
+		}
+	}
+
+	var res []string
+	for _, v := range paths {
+		if v == cfg.CurationFile {
+			continue
+		}
+		if _, err := os.Lstat(v); err != nil {
+			continue
+		}
+		err := os.RemoveAll(v)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+// variants returns all paths a file can have with compression extensions.
+func variants(path string) []string {
+	res := make([]string, len(compress.Methods))
+	for i, v := range compress.Methods {
+		res[i] = compress.Path(path, v)
+	}
+	return res
+}
+
+func cacheFile(
+	ctx context.Context,
+	cfg config.Config,
+	stage, name string,
+) (CacheFile, error) {
+	res := CacheFile{Stage: stage, Name: name}
+	path, ok := compress.Find(filepath.Join(cfg.WorkDir(), name))
+	if !ok {
+		return res, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return res, err
+	}
+	res.Path = path
+	res.Size = info.Size()
+	res.Modified = info.ModTime()
+
+	r, err := compress.Open(filepath.Join(cfg.WorkDir(), name))
+	if err != nil {
+		return res, err
+	}
+	defer r.Close()
+	res.Lines, err = countLines(ctx, r)
+	if err != nil {
+		return res, fmt.Errorf("-> countLines %s: %w", path, err)
+	}
+	return res, nil
+}
+
+// dictStatus describes the dictionary of the current build.
+func dictStatus(ctx context.Context, cfg config.Config) (CacheFile, error) {
+	res := CacheFile{Stage: StageOutput, Name: filepath.Base(cfg.DictDir())}
+	link, err := os.Lstat(cfg.DictDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+	// the link changes when a build becomes current.
+	res.Modified = link.ModTime()
+
+	dir, err := filepath.EvalSymlinks(cfg.DictDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+	res.Path = dir
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		res.Size += info.Size()
+		if filepath.Ext(path) != ".csv" {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		lines, err := countLines(ctx, f)
+		res.Lines += lines
+		return err
+	})
+	return res, err
+}
+
+// countLines counts lines of a reader.
+func countLines(ctx context.Context, r io.Reader) (int, error) {
+	var res int
+	buf := make([]byte, 1<<20)
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		n, err := r.Read(buf)
+		res += bytes.Count(buf[:n], []byte{'\n'})
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return res, err
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package ent
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/gnames/gndict/pkg/config"
+)
+
+// Check is a result of applying one classification rule to a word.
+type Check struct {
+	// Rule is the name of the rule.
+	Rule string
+	// Detail shows the value of the word and the threshold of the rule.
+	Detail string
+	// Hit is true if the rule applies to the word.
+	Hit bool
+}
+
+// greyRule is a config.GreyRule with compiled regular expressions.
+type greyRule struct {
+	config.GreyRule
+	deny []*regexp.Regexp
+}
+
+// classifier assigns words to dictionary buckets.
+type classifier struct {
+	cfg   config.Config
+	dat   *data.Data
+	sc    *scorer
+	rules map[string]greyRule
+
+	// patterns match words that cannot be parts of scientific names.
+	patterns []data.Pattern
+}
+
+func newClassifier(cfg config.Config, dat *data.Data) (*classifier, error) {
+	res := &classifier{
+		cfg:   cfg,
+		dat:   dat,
+		sc:    newScorer(dat),
+		rules: make(map[string]greyRule),
+	}
+
+	if cfg.PatternsFile != "" {
+		pats, err := readPatterns(cfg.PatternsFile)
+		if err != nil {
+			err = fmt.Errorf("-> readPatterns: %w", err)
+			return nil, err
+		}
+		res.patterns = pats
+	}
+
+	for k, v := range cfg.GreyRules {
+		rule := greyRule{GreyRule: v}
+		if rule.MinLen == 0 {
+			rule.MinLen = config.DefaultMinLen
+		}
+		if rule.Score == 0 {
+			rule.Score = cfg.ScoreAmbig
+		}
+		for _, re := range v.Deny {
+			r, err := regexp.Compile(re)
+			if err != nil {
+				err = fmt.Errorf("-> regexp.Compile %s for %s: %w", re, k, err)
+				return nil, err
+			}
+			rule.deny = append(rule.deny, r)
+		}
+		res.rules[k] = rule
+	}
+	return res, nil
+}
+
+// classify finds the bucket of a word of a category from a CSV row of
+// preprocessed data. It returns the record of the word and the row with
+// the ambiguity score added as the last field.
+func (c *classifier) classify(cat, row string) (Record, string) {
+	res, _ := c.explain(cat, row)
+	if res.Bucket == NotIn && res.Score == 0 {
+		return res, row
+	}
+	row += "," + strconv.FormatFloat(res.Score, 'f', 3, 64)
+	return res, row
+}
+
+// explain classifies a word of a category from a CSV row of preprocessed
+// data, and returns all the checks that were made.
+func (c *classifier) explain(cat, row string) (Record, []Check) {
+	name, _, _ := strings.Cut(row, ",")
+	res := Record{Name: name, Count: rowCount(row)}
+	hit := c.problems(cat, name)
+	checks := []Check{{Rule: "blacklist", Detail: hit, Hit: hit != ""}}
+	if hit != "" {
+		res.Bucket, res.BlackHit = NotIn, hit
+		return res, checks
+	}
+
+	rule := c.rule(cat)
+	sc := c.sc.score(row, rule.MinLen)
+	res.Score = sc.value
+	if c.cfg.ScoreNotIn > 0 {
+		notIn := sc.value >= c.cfg.ScoreNotIn
+		checks = append(checks, Check{
+			Rule:   "score-not-in",
+			Detail: fmt.Sprintf("%.3f >= %.3f", sc.value, c.cfg.ScoreNotIn),
+			Hit:    notIn,
+		})
+		if notIn {
+			res.Bucket, res.BlackHit = NotIn, "score"
+			return res, checks
+		}
+	}
+
+	grey := c.greyChecks(rule, res, sc)
+	checks = append(checks, grey...)
+	var reasons []string
+	for _, v := range grey {
+		if v.Hit {
+			reasons = append(reasons, v.Rule)
+		}
+	}
+	res.Bucket = In
+	if len(reasons) > 0 {
+		res.Bucket, res.GreyReason = InAmbig, strings.Join(reasons, "|")
+	}
+	return res, checks
+}
+
+// greyChecks applies grey rules to a word.
+func (c *classifier) greyChecks(
+	rule greyRule,
+	rec Record,
+	sc score,
+) []Check {
+	l := utf8.RuneCountInString(rec.Name)
+	res := []Check{
+		{
+			Rule:   "min-len",
+			Detail: fmt.Sprintf("%d < %d", l, rule.MinLen),
+			Hit:    l < rule.MinLen,
+		},
+	}
+
+	if !rule.IgnoreCommon {
+		_, isCommon := c.dat.Common[strings.ToLower(rec.Name)]
+		res = append(res, Check{
+			Rule:   "common",
+			Detail: strconv.FormatBool(isCommon),
+			Hit:    isCommon,
+		})
+	}
+
+	for _, v := range rule.deny {
+		res = append(res, Check{
+			Rule:   "deny",
+			Detail: v.String(),
+			Hit:    v.MatchString(rec.Name),
+		})
+	}
+
+	if rule.MinCount > 0 {
+		res = append(res, Check{
+			Rule:   "min-count",
+			Detail: fmt.Sprintf("%d < %d", rec.Count, rule.MinCount),
+			Hit:    rec.Count < rule.MinCount,
+		})
+	}
+
+	details := make([]string, len(sc.signals))
+	for i, v := range sc.signals {
+		details[i] = fmt.Sprintf("%s: %.3f", v.reason, v.value)
+	}
+	res = append(res, Check{
+		Rule: "score",
+		Detail: fmt.Sprintf("%.3f >= %.3f (%s)",
+			sc.value, rule.Score, strings.Join(details, ", ")),
+		Hit: sc.value >= rule.Score,
+	})
+	return res
+}
+
+// rule returns the grey rule of a category.
+func (c *classifier) rule(cat string) greyRule {
+	if res, ok := c.rules[cat]; ok {
+		return res
+	}
+	return greyRule{GreyRule: config.GreyRule{
+		MinLen: config.DefaultMinLen,
+		Score:  c.cfg.ScoreAmbig,
+	}}
+}
+
+// problems returns a reason why a word of a category cannot be in the
+// dictionary, or an empty string if there are no problems.
+func (c *classifier) problems(cat, word string) string {
+	if cat == "species" {
+		return c.speciesProblems(word)
+	}
+	return c.uninomialProblems(word)
+}
+
+// uninomialProblems returns a reason why a uninomial cannot be in
+// the dictionary, or an empty string if there are no problems.
+func (c *classifier) uninomialProblems(word string) string {
+	word = strings.ToLower(word)
+	if _, ok := c.dat.UniBlack[word]; ok {
+		return "blacklist"
+	}
+
+	if p := c.matchPattern(word); p != "" {
+		return "pattern:" + p
+	}
+
+	if strings.Contains(word, ".") {
+		return "dot"
+	}
+	return ""
+}
+
+// speciesProblems returns a reason why a specific epithet cannot be in
+// the dictionary, or an empty string if there are no problems.
+func (c *classifier) speciesProblems(sp string) string {
+	spLow := strings.ToLower(sp)
+	if _, ok := c.dat.SpBlack[spLow]; ok {
+		return "blacklist"
+	}
+
+	if p := c.matchPattern(spLow); p != "" {
+		return "pattern:" + p
+	}
+
+	if len(sp) < 2 {
+		return "short"
+	}
+
+	if strings.Contains(sp, ".") {
+		return "dot"
+	}
+
+	for _, v := range sp {
+		if unicode.IsDigit(v) {
+			return "digit"
+		}
+	}
+	return ""
+}
+
+// matchPattern returns the source of the first pattern that matches
+// a lowercase word, or an empty string.
+func (c *classifier) matchPattern(low string) string {
+	for _, v := range c.patterns {
+		if v.Match(low) {
+			return v.Source
+		}
+	}
+	return ""
+}
+
+// rowCount returns the count field of a CSV row of preprocessed data.
+func rowCount(row string) int {
+	_, rest, _ := strings.Cut(row, ",")
+	cnt, _, _ := strings.Cut(rest, ",")
+	res, _ := strconv.Atoi(cnt)
+	return res
+}
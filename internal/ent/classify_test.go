@@ -0,0 +1,206 @@
+package ent
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testData returns small word lists for classification tests.
+func testData() *data.Data {
+	return &data.Data{
+		Common:   map[string]struct{}{"alba": {}, "major": {}},
+		SpBlack:  map[string]struct{}{"sp": {}},
+		UniBlack: map[string]struct{}{"incertae": {}},
+	}
+}
+
+// findCheck returns the check of a rule and true if the rule was applied.
+func findCheck(checks []Check, rule string) (Check, bool) {
+	for _, v := range checks {
+		if v.Rule == rule {
+			return v, true
+		}
+	}
+	return Check{}, false
+}
+
+func TestGreyRules(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	tests := []struct {
+		msg, cat, row string
+		rule          config.GreyRule
+		check         string
+		applied, hit  bool
+	}{
+		{"min-len genus", "genera", "Poa,100,5",
+			config.GreyRule{MinLen: 4}, "min-len", true, true},
+		{"min-len genus ok", "genera", "Poa,100,5",
+			config.GreyRule{MinLen: 3}, "min-len", true, false},
+		// 'éta' has 3 runes, but 4 bytes.
+		{"min-len runes", "species", "\u00e9ta,100,5",
+			config.GreyRule{MinLen: 4}, "min-len", true, true},
+		{"min-len runes ok", "species", "\u00e9ta,100,5",
+			config.GreyRule{MinLen: 3}, "min-len", true, false},
+		{"common", "species", "alba,100,5",
+			config.GreyRule{}, "common", true, true},
+		{"not common", "species", "rubra,100,5",
+			config.GreyRule{}, "common", true, false},
+		{"ignore common", "species", "alba,100,5",
+			config.GreyRule{IgnoreCommon: true}, "common", false, false},
+		{"deny", "species", "alba,100,5",
+			config.GreyRule{Deny: []string{"^al"}}, "deny", true, true},
+		{"deny no match", "species", "rubra,100,5",
+			config.GreyRule{Deny: []string{"^al"}}, "deny", true, false},
+		{"no deny", "species", "alba,100,5",
+			config.GreyRule{}, "deny", false, false},
+		{"min-count", "species", "rubra,3,1",
+			config.GreyRule{MinCount: 5}, "min-count", true, true},
+		{"min-count ok", "species", "rubra,10,1",
+			config.GreyRule{MinCount: 5}, "min-count", true, false},
+		{"min-count off", "species", "rubra,3,1",
+			config.GreyRule{}, "min-count", false, false},
+	}
+	for _, v := range tests {
+		cfg := config.New(config.OptGreyRules(
+			map[string]config.GreyRule{v.cat: v.rule},
+		))
+		cl, err := newClassifier(cfg, testData())
+		require.Nil(err, v.msg)
+		rec, checks := cl.explain(v.cat, v.row)
+		ch, ok := findCheck(checks, v.check)
+		assert.Equal(v.applied, ok, v.msg)
+		assert.Equal(v.hit, ch.Hit, v.msg)
+		if v.hit {
+			assert.Equal(InAmbig, rec.Bucket, v.msg)
+			assert.Contains(rec.GreyReason, v.check, v.msg)
+		}
+	}
+}
+
+func TestClassifyBlacklist(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	cl, err := newClassifier(config.New(), testData())
+	require.Nil(err)
+	tests := []struct {
+		msg, cat, row, hit string
+	}{
+		{"species blacklist", "species", "sp,100,5", "blacklist"},
+		{"uninomial blacklist", "uninomials", "Incertae,100,5", "blacklist"},
+		{"digit", "species", "2bus,100,5", "digit"},
+		{"dot", "genera", "Ab.c,100,5", "dot"},
+	}
+	for _, v := range tests {
+		rec, checks := cl.explain(v.cat, v.row)
+		assert.Equal(NotIn, rec.Bucket, v.msg)
+		assert.Equal(v.hit, rec.BlackHit, v.msg)
+		assert.Len(checks, 1, v.msg)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	cfg := config.New(config.OptGreyRules(map[string]config.GreyRule{
+		"species": {MinCount: 5},
+	}))
+	sys := sysMock{files: map[string][]string{
+		"species.csv": {"alba,100,5", "rubra,3,1"},
+		"genera.csv":  {"Rosa,50,3"},
+	}}
+	res, err := Explain(
+		context.Background(), cfg, sys, testData(), []string{"rubra", "Rosa"},
+	)
+	require.Nil(err)
+	// every word is explained in every category.
+	require.Len(res, len(config.Categories)*2)
+	// explanations are grouped by word.
+	for i, v := range res {
+		word := "rubra"
+		if i >= len(config.Categories) {
+			word = "Rosa"
+		}
+		assert.Equal(word, v.Word)
+		assert.Equal(config.Categories[i%len(config.Categories)], v.Category)
+	}
+
+	found := make(map[string]Explanation)
+	for _, v := range res {
+		found[v.Category+"/"+v.Word] = v
+	}
+
+	sp := found["species/rubra"]
+	assert.True(sp.Found)
+	assert.Equal(3, sp.Record.Count)
+	assert.Equal(InAmbig, sp.Record.Bucket)
+	ch, ok := findCheck(sp.Checks, "min-count")
+	assert.True(ok)
+	assert.True(ch.Hit)
+	assert.Equal("3 < 5", ch.Detail)
+
+	gen := found["genera/Rosa"]
+	assert.True(gen.Found)
+	assert.Equal(50, gen.Record.Count)
+	_, ok = findCheck(gen.Checks, "min-count")
+	assert.False(ok)
+
+	uni := found["uninomials/Rosa"]
+	assert.False(uni.Found)
+	assert.Equal(0, uni.Record.Count)
+}
+
+func TestPatternsFile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	tests := []struct {
+		msg, cat, row, hit string
+	}{
+		{"glob", "uninomials", "Biology,10,1", "pattern:glob:*ology"},
+		{"regexp", "species", "gastrectomy,10,1",
+			"pattern:^[a-z]+(ectomy|otomy|algia)$"},
+		{"no vowels", "species", "mnst,10,1",
+			"pattern:^[bcdfghjklmnpqrstvwxz]{3,}$"},
+		{"no match", "species", "rubra,10,1", ""},
+	}
+	roman := `^(x{1,3}(ix|iv|v?i{0,3})|ix|iv|v?i{1,3}|v)$`
+	// words shorter than 3 letters are blacklisted before patterns.
+	for _, v := range []string{"iii", "viii", "xiv", "xxi", "xxxix"} {
+		tests = append(tests, struct{ msg, cat, row, hit string }{
+			"roman " + v, "species", v + ",10,1", "pattern:" + roman,
+		})
+	}
+	for _, v := range []string{"iiii", "ixi", "vix", "xixi"} {
+		tests = append(tests, struct{ msg, cat, row, hit string }{
+			"not roman " + v, "species", v + ",10,1", "",
+		})
+	}
+	re := regexp.MustCompile(roman)
+	for _, v := range []string{"i", "iv", "v", "ix", "x", "xi"} {
+		assert.True(re.MatchString(v), "roman "+v)
+	}
+	for _, v := range []string{"", "vv", "ivi"} {
+		assert.False(re.MatchString(v), "not roman "+v)
+	}
+
+	cl, err := newClassifier(config.New(), testData())
+	require.Nil(err)
+	for _, v := range tests {
+		rec, _ := cl.explain(v.cat, v.row)
+		assert.Empty(rec.BlackHit, "no patterns without PatternsFile: "+v.msg)
+	}
+
+	cfg := config.New(config.OptPatternsFile("../../examples/patterns-black.txt"))
+	cl, err = newClassifier(cfg, testData())
+	require.Nil(err)
+	for _, v := range tests {
+		rec, _ := cl.explain(v.cat, v.row)
+		assert.Equal(v.hit, rec.BlackHit, v.msg)
+	}
+}
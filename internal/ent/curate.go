@@ -0,0 +1,130 @@
+package ent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/gnames/gndict/pkg/config"
+)
+
+// englishSuffix matches endings that are typical for English words, but
+// not for Latin names.
+var englishSuffix = regexp.MustCompile(`(tion|ness|ment|ship|ing|ful|less)$`)
+
+// Candidate is a word proposed for a blacklist.
+type Candidate struct {
+	// List is the blacklist, data.SpeciesBlack or data.UninomialsBlack.
+	List string
+	// Word is the proposed word.
+	Word string
+	// Count is the number of names with the word.
+	Count int
+	// Reason explains why the word is proposed.
+	Reason string
+}
+
+// Candidates finds words that might need to be added to blacklists:
+// high-count epithets that are common words, uninomials that look like
+// English words, and words of 'in' dictionaries that are common words.
+// Words that are already blacklisted or have a curation decision are
+// skipped.
+func Candidates(
+	ctx context.Context,
+	cfg config.Config,
+	sys Sys,
+	dat *data.Data,
+	minCount int,
+) ([]Candidate, error) {
+	ds, err := data.ReadCuration(cfg.CurationFile)
+	if err != nil {
+		err = fmt.Errorf("-> data.ReadCuration: %w", err)
+		return nil, err
+	}
+	seen := make(map[string]struct{})
+	for _, v := range ds {
+		seen[v.List+"\t"+strings.ToLower(v.Word)] = struct{}{}
+	}
+
+	var res []Candidate
+	add := func(c Candidate) {
+		low := strings.ToLower(c.Word)
+		key := c.List + "\t" + low
+		if _, ok := seen[key]; ok {
+			return
+		}
+		black := dat.UniBlack
+		if c.List == data.SpeciesBlack {
+			black = dat.SpBlack
+		}
+		if _, ok := black[low]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		res = append(res, c)
+	}
+
+	lines, err := sys.ReadFile(ctx, "species.csv")
+	if err != nil {
+		err = fmt.Errorf("-> sys.ReadFile: %w", err)
+		return nil, err
+	}
+	for _, v := range lines {
+		word, _, _ := strings.Cut(v, ",")
+		cnt := rowCount(v)
+		if cnt < minCount {
+			continue
+		}
+		if _, ok := dat.Common[word]; ok {
+			add(Candidate{data.SpeciesBlack, word, cnt, "common"})
+		}
+	}
+
+	lines, err = sys.ReadFile(ctx, "uninomials.csv")
+	if err != nil {
+		err = fmt.Errorf("-> sys.ReadFile: %w", err)
+		return nil, err
+	}
+	for _, v := range lines {
+		word, _, _ := strings.Cut(v, ",")
+		low := strings.ToLower(word)
+		if _, ok := dat.Common[low]; ok {
+			add(Candidate{data.UninomialsBlack, word, rowCount(v), "common"})
+			continue
+		}
+		if m := englishSuffix.FindString(low); m != "" {
+			add(Candidate{data.UninomialsBlack, word, rowCount(v), "suffix:-" + m})
+		}
+	}
+
+	for _, v := range []string{"uninomials", "genera", "species"} {
+		list := data.UninomialsBlack
+		if v == "species" {
+			list = data.SpeciesBlack
+		}
+		path := filepath.Join(cfg.DictDir(), "in", v+".csv")
+		bs, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range strings.Split(string(bs), "\n") {
+			word, _, _ := strings.Cut(row, ",")
+			if _, ok := dat.Common[strings.ToLower(word)]; ok {
+				add(Candidate{list, word, rowCount(row), "in-common"})
+			}
+		}
+	}
+
+	slices.SortStableFunc(res, func(a, b Candidate) int {
+		return b.Count - a.Count
+	})
+	return res, nil
+}
@@ -0,0 +1,108 @@
+package data
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Lists that can be extended by curation.
+const (
+	SpeciesBlack    = "species-black"
+	UninomialsBlack = "uninomials-black"
+)
+
+// CommonWords is the name of common-words lists, they can be extended by
+// files, but not by curation.
+const CommonWords = "common"
+
+// Decision is a curator's decision about adding a word to a list.
+type Decision struct {
+	// List is the name of the list, SpeciesBlack or UninomialsBlack.
+	List string
+	// Word is the word to add to the list.
+	Word string
+	// Accept is true if the word should be added to the list. Rejected
+	// words are kept so they are not proposed again.
+	Accept bool
+}
+
+// ReadCuration reads decisions from a curation file. The file has
+// 'list<TAB>word<TAB>accept|reject' rows. If the file does not exist,
+// it returns no decisions.
+func ReadCuration(path string) ([]Decision, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var res []Decision
+	scanner := bufio.NewScanner(f)
+	var i int
+	for scanner.Scan() {
+		i++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("bad curation line %d: '%s'", i, line)
+		}
+		d := Decision{List: fields[0], Word: fields[1]}
+		switch fields[2] {
+		case "accept":
+			d.Accept = true
+		case "reject":
+		default:
+			return nil, fmt.Errorf("bad decision on line %d: '%s'", i, fields[2])
+		}
+		res = append(res, d)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// AppendCuration adds decisions to the end of a curation file.
+func AppendCuration(path string, ds []Decision) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	for _, v := range ds {
+		decision := "reject"
+		if v.Accept {
+			decision = "accept"
+		}
+		_, err = fmt.Fprintf(f, "%s\t%s\t%s\n", v.List, v.Word, decision)
+		if err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// Merge adds accepted words to the blacklists.
+func (d *Data) Merge(ds []Decision) {
+	for _, v := range ds {
+		if !v.Accept {
+			continue
+		}
+		word := strings.ToLower(v.Word)
+		switch v.List {
+		case SpeciesBlack:
+			d.SpBlack[word] = struct{}{}
+		case UninomialsBlack:
+			d.UniBlack[word] = struct{}{}
+		}
+	}
+}
@@ -2,7 +2,6 @@ package data
 
 import (
 	_ "embed"
-	"strings"
 )
 
 //go:embed static/common-eu-words.txt
@@ -20,23 +19,23 @@ var uniBlack string
 type Data struct {
 	Common, ION, SpBlack, UniBlack map[string]struct{}
 	GenSp                          map[string][]string
+
+	// CommonLists keeps lists of common words by their names. Common contains
+	// words of all the lists.
+	CommonLists map[string]map[string]struct{}
 }
 
 func New() *Data {
-	return &Data{
-		Common:   toMap(common),
-		ION:      toMap(ion),
-		SpBlack:  toMap(spBlack),
-		UniBlack: toMap(uniBlack),
+	lists := make(map[string]map[string]struct{})
+	for _, v := range embedded() {
+		lists[v.name], _ = LoadList(v.name, v.text, v.lower)
 	}
-}
-
-func toMap(s string) map[string]struct{} {
-	res := make(map[string]struct{})
-	lines := strings.Split(s, "\n")
-	for _, v := range lines {
-		v = strings.TrimSpace(v)
-		res[v] = struct{}{}
+	com := lists["common-eu-words.txt"]
+	return &Data{
+		Common:      com,
+		ION:         lists["ion-names.txt"],
+		SpBlack:     lists["species-black.txt"],
+		UniBlack:    lists["uninomials-black.txt"],
+		CommonLists: map[string]map[string]struct{}{"eu": com},
 	}
-	return res
 }
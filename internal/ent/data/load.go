@@ -0,0 +1,149 @@
+package data
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Kinds of issues in word lists.
+const (
+	// IssueEmpty is an empty line.
+	IssueEmpty = "empty"
+	// IssueBlank is a line that contains only whitespace.
+	IssueBlank = "blank"
+	// IssueWhitespace is a word with leading or trailing whitespace.
+	IssueWhitespace = "whitespace"
+	// IssueCase is a word with uppercase letters in a lowercase list.
+	IssueCase = "case"
+	// IssueDuplicate is a word that appears in a list more than once.
+	IssueDuplicate = "duplicate"
+)
+
+// Issue is a problem found in a word list.
+type Issue struct {
+	// List is the name of the list.
+	List string
+	// Line is the line number, starting from 1.
+	Line int
+	// Kind is the kind of the problem.
+	Kind string
+	// Value is the content of the line.
+	Value string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s:%d: %s %q", i.List, i.Line, i.Kind, i.Value)
+}
+
+// LoadList parses a word list with one word per line. Lines that start with
+// '#' are comments. Empty lines, blank lines and duplicates are skipped,
+// whitespace around words is removed. If lower is true, words are converted
+// to lowercase. Words are converted to the Unicode NFC form. All problems
+// are reported as issues.
+func LoadList(
+	name, text string,
+	lower bool,
+) (map[string]struct{}, []Issue) {
+	res := make(map[string]struct{})
+	var issues []Issue
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return res, nil
+	}
+	for i, v := range strings.Split(text, "\n") {
+		v = strings.TrimSuffix(v, "\r")
+		issue := Issue{List: name, Line: i + 1, Value: v}
+		word := strings.TrimSpace(v)
+		switch {
+		case v == "":
+			issue.Kind = IssueEmpty
+			issues = append(issues, issue)
+			continue
+		case word == "":
+			issue.Kind = IssueBlank
+			issues = append(issues, issue)
+			continue
+		case strings.HasPrefix(word, "#"):
+			continue
+		case word != v:
+			issue.Kind = IssueWhitespace
+			issues = append(issues, issue)
+		}
+
+		word = norm.NFC.String(word)
+		if lower && strings.ToLower(word) != word {
+			issue.Kind = IssueCase
+			issues = append(issues, issue)
+			word = strings.ToLower(word)
+		}
+
+		if _, ok := res[word]; ok {
+			issue.Kind = IssueDuplicate
+			issues = append(issues, issue)
+			continue
+		}
+		res[word] = struct{}{}
+	}
+	return res, issues
+}
+
+// LoadFile reads a word list from a file.
+func LoadFile(path string, lower bool) (map[string]struct{}, []Issue, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	res, issues := LoadList(path, string(bs), lower)
+	return res, issues, nil
+}
+
+// AddFile adds words of a file to the list with the name. A file of
+// CommonWords becomes a separate list of CommonLists, files of blacklists
+// extend the blacklists.
+func (d *Data) AddFile(list, path string) error {
+	words, _, err := LoadFile(path, true)
+	if err != nil {
+		return err
+	}
+	switch list {
+	case CommonWords:
+		d.CommonLists[path] = words
+		maps.Copy(d.Common, words)
+	case SpeciesBlack:
+		maps.Copy(d.SpBlack, words)
+	case UninomialsBlack:
+		maps.Copy(d.UniBlack, words)
+	default:
+		return fmt.Errorf("unknown list '%s'", list)
+	}
+	return nil
+}
+
+// Lint returns issues of the embedded lists.
+func Lint() []Issue {
+	var res []Issue
+	for _, v := range embedded() {
+		_, issues := LoadList(v.name, v.text, v.lower)
+		res = append(res, issues...)
+	}
+	return res
+}
+
+// list is an embedded word list.
+type list struct {
+	name, text string
+	lower      bool
+}
+
+func embedded() []list {
+	return []list{
+		{"common-eu-words.txt", common, true},
+		{"ion-names.txt", ion, false},
+		{"species-black.txt", spBlack, true},
+		{"uninomials-black.txt", uniBlack, true},
+	}
+}
@@ -0,0 +1,86 @@
+package data_test
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadList(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		msg, text string
+		lower     bool
+		words     []string
+		issues    []data.Issue
+	}{
+		{"empty list", "", true, nil, nil},
+		{"words", "alba\nrubra\n", true, []string{"alba", "rubra"}, nil},
+		{"crlf", "alba\r\nrubra\r\n", true, []string{"alba", "rubra"}, nil},
+		{"comments", "# words\nalba\n  # indented\n", true,
+			[]string{"alba"}, nil},
+		{"empty line", "alba\n\nrubra", true, []string{"alba", "rubra"},
+			[]data.Issue{{List: "l", Line: 2, Kind: data.IssueEmpty}}},
+		{"blank line", "alba\n \t\nrubra", true, []string{"alba", "rubra"},
+			[]data.Issue{{List: "l", Line: 2, Kind: data.IssueBlank,
+				Value: " \t"}}},
+		{"whitespace", " alba\t", true, []string{"alba"},
+			[]data.Issue{{List: "l", Line: 1, Kind: data.IssueWhitespace,
+				Value: " alba\t"}}},
+		{"case", "Alba", true, []string{"alba"},
+			[]data.Issue{{List: "l", Line: 1, Kind: data.IssueCase,
+				Value: "Alba"}}},
+		{"case kept", "Alba", false, []string{"Alba"}, nil},
+		{"duplicate", "alba\nrubra\nalba", true, []string{"alba", "rubra"},
+			[]data.Issue{{List: "l", Line: 3, Kind: data.IssueDuplicate,
+				Value: "alba"}}},
+		{"duplicate after lowercase", "alba\nAlba", true, []string{"alba"},
+			[]data.Issue{
+				{List: "l", Line: 2, Kind: data.IssueCase, Value: "Alba"},
+				{List: "l", Line: 2, Kind: data.IssueDuplicate, Value: "Alba"},
+			}},
+		{"nfc", "cafe\u0301\ncaf\u00e9", true, []string{"caf\u00e9"},
+			[]data.Issue{{List: "l", Line: 2, Kind: data.IssueDuplicate,
+				Value: "caf\u00e9"}}},
+	}
+	for _, v := range tests {
+		res, issues := data.LoadList("l", v.text, v.lower)
+		words := slices.Sorted(maps.Keys(res))
+		if v.words == nil {
+			assert.Empty(words, v.msg)
+		} else {
+			assert.Equal(v.words, words, v.msg)
+		}
+		assert.Equal(v.issues, issues, v.msg)
+	}
+}
+
+func TestAddFile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	path := filepath.Join(t.TempDir(), "words.txt")
+	err := os.WriteFile(path, []byte("# words\nHund\nkatze\n"), 0644)
+	require.Nil(err)
+
+	dat := data.New()
+	lists := len(dat.CommonLists)
+	require.Nil(dat.AddFile(data.CommonWords, path))
+	assert.Len(dat.CommonLists, lists+1)
+	assert.Contains(dat.CommonLists[path], "hund")
+	assert.Contains(dat.Common, "katze")
+
+	require.Nil(dat.AddFile(data.SpeciesBlack, path))
+	assert.Contains(dat.SpBlack, "hund")
+	require.Nil(dat.AddFile(data.UninomialsBlack, path))
+	assert.Contains(dat.UniBlack, "katze")
+
+	assert.NotNil(dat.AddFile("genera", path))
+	err = dat.AddFile(data.CommonWords, path+".none")
+	assert.ErrorIs(err, os.ErrNotExist)
+}
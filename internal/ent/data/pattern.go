@@ -0,0 +1,67 @@
+package data
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// globPrefix marks patterns that are globs instead of regular expressions.
+const globPrefix = "glob:"
+
+// Pattern matches words that cannot be a part of scientific names.
+type Pattern struct {
+	// Source is the pattern as it was written.
+	Source string
+	re     *regexp.Regexp
+	glob   string
+}
+
+// NewPattern creates a Pattern from a regular expression, or from a glob,
+// if the source starts with 'glob:'.
+func NewPattern(src string) (Pattern, error) {
+	res := Pattern{Source: src}
+	if glob, ok := strings.CutPrefix(src, globPrefix); ok {
+		_, err := path.Match(glob, "")
+		if err != nil {
+			return res, fmt.Errorf("bad glob '%s': %w", glob, err)
+		}
+		res.glob = glob
+		return res, nil
+	}
+
+	re, err := regexp.Compile(src)
+	if err != nil {
+		return res, fmt.Errorf("bad regexp '%s': %w", src, err)
+	}
+	res.re = re
+	return res, nil
+}
+
+// Match checks if a word matches the pattern.
+func (p Pattern) Match(word string) bool {
+	if p.re != nil {
+		return p.re.MatchString(word)
+	}
+	ok, _ := path.Match(p.glob, word)
+	return ok
+}
+
+// ParsePatterns creates patterns from text with one pattern per line.
+// Empty lines and lines that start with '#' are ignored.
+func ParsePatterns(text string) ([]Pattern, error) {
+	var res []Pattern
+	for _, v := range strings.Split(text, "\n") {
+		v = strings.TrimSpace(v)
+		if v == "" || strings.HasPrefix(v, "#") {
+			continue
+		}
+		p, err := NewPattern(v)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
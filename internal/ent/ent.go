@@ -1,21 +1,41 @@
 package ent
 
-import "github.com/gnames/gndict/internal/ent/data"
+import (
+	"context"
+
+	"github.com/gnames/gndict/internal/ent/data"
+)
 
 type Downloader interface {
 	// Download connects to gnames database and downloads canonical forms of
 	// names from sources that are considered to be 'reliable'.
 	// Then it downloads generic names from the IRMNG project.
 	// These files will be first preprocessed, than the data will be converted
-	// to the output for gnfinder.
-	Download(dat *data.Data) error
+	// to the output for gnfinder. If ctx is canceled, partially downloaded
+	// files are removed.
+	Download(ctx context.Context, dat *data.Data) error
 	// Close cleans up database connections.
 	Close() error
 }
 
+// Sys reads downloaded and preprocessed files. Reading stops with an error
+// if ctx is canceled.
 type Sys interface {
-	Names() ([]string, error)
-	Canonicals() ([]string, error)
-	Genera() ([]string, error)
-	ReadFile(path string) ([]string, error)
+	Names(ctx context.Context) ([]string, error)
+	Canonicals(ctx context.Context) ([]string, error)
+	Genera(ctx context.Context) ([]string, error)
+	// Kingdoms returns 'genus,kingdom' rows.
+	Kingdoms(ctx context.Context) ([]string, error)
+	ReadFile(ctx context.Context, path string) ([]string, error)
+}
+
+// Store saves dictionary records to a database, so they can be queried by
+// curators.
+type Store interface {
+	// Init prepares an empty database.
+	Init() error
+	// Save adds records to a table of the database.
+	Save(table string, recs []Record) error
+	// Close finishes work with the database.
+	Close() error
 }
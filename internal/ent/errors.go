@@ -0,0 +1,20 @@
+package ent
+
+import "errors"
+
+var (
+	// ErrNoDatabase means that the gnames database cannot be reached.
+	ErrNoDatabase = errors.New("cannot connect to the database")
+
+	// ErrCacheMissing means that a file of a previous stage is not in the
+	// cache, for example Output runs before Download or Preprocess.
+	ErrCacheMissing = errors.New("file is missing from the cache")
+
+	// ErrEmptyDownload means that the database returned no names or genera,
+	// for example because of a wrong scope or an empty database.
+	ErrEmptyDownload = errors.New("download is empty")
+
+	// ErrBuildExists means that the directory of the BuildID is already
+	// in the BuildsDir.
+	ErrBuildExists = errors.New("build already exists")
+)
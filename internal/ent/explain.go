@@ -0,0 +1,89 @@
+package ent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/gnames/gndict/pkg/config"
+)
+
+// Explanation describes how a word is classified in a category.
+type Explanation struct {
+	// Word is the explained word.
+	Word string
+	// Category is uninomials, genera or species.
+	Category string
+	// Found is true if the word is in the preprocessed data of the category.
+	Found bool
+	// Record is the result of the classification.
+	Record Record
+	// Checks are the results of all the rules applied to the word.
+	Checks []Check
+}
+
+// Explain shows how words are classified in every category. It uses
+// preprocessed data, so Preprocess must run before. Words that are not
+// found in the data of a category are classified as if they had zero count.
+// Explanations are grouped by word in the order of words, and by category
+// within a word.
+func Explain(
+	ctx context.Context,
+	cfg config.Config,
+	sys Sys,
+	dat *data.Data,
+	words []string,
+) ([]Explanation, error) {
+	cl, err := newClassifier(cfg, dat)
+	if err != nil {
+		err = fmt.Errorf("-> newClassifier: %w", err)
+		return nil, err
+	}
+	kingdoms, err := readKingdoms(ctx, sys)
+	if err != nil {
+		err = fmt.Errorf("-> readKingdoms: %w", err)
+		return nil, err
+	}
+	cl.sc.setKingdoms(kingdomCounts(kingdoms))
+
+	words = slices.Clone(words)
+	for i := range words {
+		words[i] = nfc(words[i])
+	}
+	catRows := make(map[string]map[string]string)
+	for _, cat := range config.Categories {
+		lines, err := sys.ReadFile(ctx, cat+".csv")
+		if err != nil {
+			err = fmt.Errorf("-> sys.ReadFile: %w", err)
+			return nil, err
+		}
+		rows := make(map[string]string)
+		for _, v := range words {
+			rows[v] = ""
+		}
+		for _, v := range lines {
+			name, _, _ := strings.Cut(v, ",")
+			if _, ok := rows[name]; ok {
+				rows[name] = v
+			}
+		}
+		catRows[cat] = rows
+	}
+
+	var res []Explanation
+	for _, word := range words {
+		for _, cat := range config.Categories {
+			exp := Explanation{Word: word, Category: cat}
+			row := catRows[cat][word]
+			exp.Found = row != ""
+			if !exp.Found {
+				row = word + ",0,0"
+			}
+			exp.Record, exp.Checks = cl.explain(cat, row)
+			res = append(res, exp)
+		}
+	}
+	return res, nil
+}
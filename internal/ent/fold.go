@@ -0,0 +1,82 @@
+package ent
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// germanFold contains transliterations of German umlauts.
+var germanFold = map[rune]string{
+	'ä': "ae", 'ö': "oe", 'ü': "ue",
+	'Ä': "Ae", 'Ö': "Oe", 'Ü': "Ue",
+}
+
+// letterFold contains transliterations of letters that do not decompose
+// to a Latin letter and a diacritic mark.
+var letterFold = map[rune]string{
+	'æ': "ae", 'Æ': "Ae", 'œ': "oe", 'Œ': "Oe",
+	'ø': "o", 'Ø': "O", 'ł': "l", 'Ł': "L",
+	'đ': "d", 'Đ': "D", 'ð': "d", 'Ð': "D",
+	'þ': "th", 'Þ': "Th", 'ß': "ss", 'ı': "i",
+}
+
+// nfc converts a string to the Unicode Normalization Form C, so the same
+// word with precomposed or decomposed characters is the same string.
+func nfc(s string) string {
+	return norm.NFC.String(s)
+}
+
+// asciiFold returns ASCII variants of a word that has non-ASCII letters.
+// For example 'Müller' gives 'Mueller' and 'Muller'. Words that are already
+// ASCII return nil.
+func asciiFold(word string) []string {
+	if isASCII(word) {
+		return nil
+	}
+
+	var res []string
+	for _, v := range []map[rune]string{germanFold, nil} {
+		f := fold(word, v)
+		if !isASCII(f) || f == "" {
+			continue
+		}
+		if len(res) == 0 || res[0] != f {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// fold transliterates runes from the special map first, then removes
+// diacritics and decomposes ligatures.
+func fold(word string, special map[rune]string) string {
+	var b strings.Builder
+	for _, v := range word {
+		if s, ok := special[v]; ok {
+			b.WriteString(s)
+			continue
+		}
+		if s, ok := letterFold[v]; ok {
+			b.WriteString(s)
+			continue
+		}
+		for _, r := range norm.NFKD.String(string(v)) {
+			if unicode.Is(unicode.Mn, r) {
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package ent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNFC(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		msg, word, res string
+	}{
+		{"ascii", "Aedes", "Aedes"},
+		{"precomposed", "Aédes", "Aédes"},
+		{"combining acute", "Ae\u0301des", "A\u00e9des"},
+		{"combining diaeresis", "Mu\u0308lleri", "M\u00fclleri"},
+		{"ligature stays", "ægypti", "ægypti"},
+	}
+	for _, v := range tests {
+		assert.Equal(v.res, nfc(v.word), v.msg)
+	}
+}
+
+func TestASCIIFold(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		msg, word string
+		res       []string
+	}{
+		{"ascii", "Aedes", nil},
+		{"acute", "Aédes", []string{"Aedes"}},
+		{"decomposed acute", "Ae\u0301des", []string{"Aedes"}},
+		{"umlaut", "muelleri", nil},
+		{"german umlaut", "mülleri", []string{"muelleri", "mulleri"}},
+		{"capital umlaut", "Ötzi", []string{"Oetzi", "Otzi"}},
+		{"ae ligature", "æquatorialis", []string{"aequatorialis"}},
+		{"oe ligature", "Œnothera", []string{"Oenothera"}},
+		{"several marks", "čeština", []string{"cestina"}},
+		{"cedilla", "façadei", []string{"facadei"}},
+		{"stroke", "łodzi", []string{"lodzi"}},
+		{"eszett", "straßei", []string{"strassei"}},
+		{"umlaut and ligature", "ænüs", []string{"aenues", "aenus"}},
+		{"no latin letter", "αβ", nil},
+	}
+	for _, v := range tests {
+		assert.Equal(v.res, asciiFold(v.word), v.msg)
+	}
+}
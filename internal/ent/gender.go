@@ -0,0 +1,102 @@
+package ent
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// minEpithetLen is the smallest length of an epithet for which gender
+// variants are generated.
+const minEpithetLen = 4
+
+// keepE contains masculine adjectives in -er that keep 'e' in feminine
+// and neuter forms, as in 'asper', 'aspera', 'asperum'. Compounds in -ifer
+// and -iger keep it too.
+var keepE = map[string]struct{}{
+	"asper": {}, "gibber": {}, "lacer": {}, "liber": {}, "miser": {},
+	"prosper": {}, "tener": {},
+}
+
+// genderVariants returns other gender forms of a Latin adjectival epithet.
+// It covers -us/-a/-um, -is/-e and -er/-ra/-rum declensions. Endings -a
+// and -ium are also endings of nouns ('vespa', genitive plural 'montium'),
+// so their variants are returned only if known epithets contain one of the
+// other forms.
+func genderVariants(ep string, known map[string]int) []string {
+	if utf8.RuneCountInString(ep) < minEpithetLen {
+		return nil
+	}
+	switch {
+	// -orum and -arum are genitive plural endings of nouns, as in
+	// 'hortorum' or 'plantarum'.
+	case strings.HasSuffix(ep, "orum"), strings.HasSuffix(ep, "arum"):
+		return nil
+	case strings.HasSuffix(ep, "er") && keepsE(ep):
+		return endings(ep, 0, "a", "um")
+	case strings.HasSuffix(ep, "era") && keepsE(ep[:len(ep)-1]):
+		return endings(ep, 1, "", "um")
+	case strings.HasSuffix(ep, "erum") && keepsE(ep[:len(ep)-2]):
+		return endings(ep, 2, "", "a")
+	case strings.HasSuffix(ep, "rum") && afterConsonant(ep, 3):
+		return endings(ep, 3, "er", "ra")
+	case strings.HasSuffix(ep, "ra") && afterConsonant(ep, 2):
+		return endings(ep, 2, "er", "rum")
+	case strings.HasSuffix(ep, "er") && afterConsonant(ep, 2):
+		return endings(ep, 2, "ra", "rum")
+	case strings.HasSuffix(ep, "us"):
+		return endings(ep, 2, "a", "um")
+	case strings.HasSuffix(ep, "ium"):
+		return ifKnown(endings(ep, 2, "us", "a"), known)
+	case strings.HasSuffix(ep, "um"):
+		return endings(ep, 2, "us", "a")
+	case strings.HasSuffix(ep, "a"):
+		return ifKnown(endings(ep, 1, "us", "um"), known)
+	case strings.HasSuffix(ep, "is"):
+		return endings(ep, 2, "e")
+	// -ae is a genitive ending, not a neuter form of an adjective.
+	case strings.HasSuffix(ep, "e") && !strings.HasSuffix(ep, "ae"):
+		return endings(ep, 1, "is")
+	}
+	return nil
+}
+
+// endings removes the ending of the given length from a word and adds
+// new endings to the stem.
+func endings(ep string, endLen int, ends ...string) []string {
+	stem := ep[:len(ep)-endLen]
+	res := make([]string, len(ends))
+	for i := range ends {
+		res[i] = stem + ends[i]
+	}
+	return res
+}
+
+// ifKnown returns variants if at least one of them is a known epithet.
+func ifKnown(vars []string, known map[string]int) []string {
+	for _, v := range vars {
+		if _, ok := known[v]; ok {
+			return vars
+		}
+	}
+	return nil
+}
+
+// keepsE checks if a masculine adjective in -er keeps 'e' in other forms.
+func keepsE(masc string) bool {
+	if _, ok := keepE[masc]; ok {
+		return true
+	}
+	// 'niger' and 'piger' are not compounds.
+	return len(masc) > 5 &&
+		(strings.HasSuffix(masc, "ifer") || strings.HasSuffix(masc, "iger"))
+}
+
+// afterConsonant checks if the ending of the given length follows
+// a consonant, as in 'nig-ra'.
+func afterConsonant(ep string, endLen int) bool {
+	if len(ep) <= endLen {
+		return false
+	}
+	c := ep[len(ep)-endLen-1]
+	return c < utf8.RuneSelf && !strings.ContainsRune("aeiouy", rune(c))
+}
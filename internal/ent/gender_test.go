@@ -0,0 +1,45 @@
+package ent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenderVariants(t *testing.T) {
+	assert := assert.New(t)
+	known := map[string]int{"albus": 3, "dubius": 1}
+	tests := []struct {
+		msg, ep string
+		res     []string
+	}{
+		{"-us", "albus", []string{"alba", "album"}},
+		{"-a", "alba", []string{"albus", "album"}},
+		{"-um", "album", []string{"albus", "alba"}},
+		{"-er", "niger", []string{"nigra", "nigrum"}},
+		{"-ra", "nigra", []string{"niger", "nigrum"}},
+		{"-rum", "nigrum", []string{"niger", "nigra"}},
+		{"short -er", "ater", []string{"atra", "atrum"}},
+		{"short -ra", "atra", []string{"ater", "atrum"}},
+		{"-er keeps e", "asper", []string{"aspera", "asperum"}},
+		{"-er keeps e tener", "tener", []string{"tenera", "tenerum"}},
+		{"-er keeps e liber", "liber", []string{"libera", "liberum"}},
+		{"-era", "aspera", []string{"asper", "asperum"}},
+		{"-erum", "tenerum", []string{"tener", "tenera"}},
+		{"-ifer", "lanifer", []string{"lanifera", "laniferum"}},
+		{"-iger", "setigera", []string{"setiger", "setigerum"}},
+		{"-is", "vulgaris", []string{"vulgare"}},
+		{"-e", "vulgare", []string{"vulgaris"}},
+		{"-a noun", "vespa", nil},
+		{"-ium genitive", "montium", nil},
+		{"-ium adjective", "dubium", []string{"dubius", "dubia"}},
+		{"-ae", "smithiae", nil},
+		{"-orum", "hortorum", nil},
+		{"-arum", "plantarum", nil},
+		{"short", "aus", nil},
+		{"no ending", "smithii", nil},
+	}
+	for _, v := range tests {
+		assert.Equal(v.res, genderVariants(v.ep, known), v.msg)
+	}
+}
@@ -0,0 +1,113 @@
+package ent
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gnames/gnfmt"
+)
+
+// kingdomSyn normalizes some of the kingdom names used by data sources.
+var kingdomSyn = map[string]string{
+	"animals":       "Animalia",
+	"metazoa":       "Animalia",
+	"plants":        "Plantae",
+	"viridiplantae": "Plantae",
+	"fungus":        "Fungi",
+	"virus":         "Viruses",
+	"monera":        "Bacteria",
+	"prokaryota":    "Bacteria",
+}
+
+// homonyms saves genera that are used in more than one kingdom. Such
+// genera are often governed by different nomenclatural codes, so the same
+// name means different taxa. The rows have 'genus,kingdoms,common' format,
+// where kingdoms are separated by '|', and common is true if the genus is
+// also a common word.
+func (o *Output) homonyms() error {
+	kingdoms := make(map[string][]string)
+	for gen, v := range o.kingdoms {
+		if bucket, ok := o.genBucket[gen]; !ok || bucket == NotIn {
+			continue
+		}
+		kingdoms[gen] = v
+	}
+
+	var res []string
+	for gen, ks := range kingdoms {
+		if len(ks) < 2 {
+			continue
+		}
+		_, isCommon := o.dat.Common[strings.ToLower(gen)]
+		row := gnfmt.ToCSV(
+			[]string{gen, strings.Join(ks, "|"), strconv.FormatBool(isCommon)},
+			',',
+		)
+		res = append(res, row)
+	}
+	slices.Sort(res)
+	return o.saveStrings("in-ambig/homonyms.csv", res)
+}
+
+// readKingdoms returns sorted kingdoms of genera from 'genus,kingdom'
+// rows.
+func readKingdoms(ctx context.Context, sys Sys) (map[string][]string, error) {
+	lines, err := sys.Kingdoms(ctx)
+	if err != nil {
+		err = fmt.Errorf("-> sys.Kingdoms: %w", err)
+		return nil, err
+	}
+
+	set := make(map[string]map[string]struct{})
+	for _, v := range lines {
+		gen, kingdom, ok := strings.Cut(v, ",")
+		if !ok {
+			continue
+		}
+		// words of dictionaries are in NFC.
+		gen = nfc(strings.TrimSpace(gen))
+		kingdom = normKingdom(kingdom)
+		if gen == "" || kingdom == "" {
+			continue
+		}
+		if _, ok := set[gen]; !ok {
+			set[gen] = make(map[string]struct{})
+		}
+		set[gen][kingdom] = struct{}{}
+	}
+
+	res := make(map[string][]string, len(set))
+	for gen, v := range set {
+		res[gen] = slices.Sorted(maps.Keys(v))
+	}
+	return res, nil
+}
+
+// kingdomCounts returns the numbers of kingdoms of genera.
+func kingdomCounts(kingdoms map[string][]string) map[string]int {
+	res := make(map[string]int, len(kingdoms))
+	for k, v := range kingdoms {
+		res[k] = len(v)
+	}
+	return res
+}
+
+// normKingdom converts a kingdom name to its canonical spelling.
+func normKingdom(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	low := strings.ToLower(s)
+	if res, ok := kingdomSyn[low]; ok {
+		return res
+	}
+	r, size := utf8.DecodeRuneInString(low)
+	return string(unicode.ToUpper(r)) + low[size:]
+}
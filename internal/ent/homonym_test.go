@@ -0,0 +1,41 @@
+package ent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormKingdom(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		msg, kingdom, res string
+	}{
+		{"empty", "  ", ""},
+		{"synonym", "Metazoa", "Animalia"},
+		{"synonym case", "VIRIDIPLANTAE", "Plantae"},
+		{"capitalize", "fungi", "Fungi"},
+		{"multibyte", "élan", "Élan"},
+	}
+	for _, v := range tests {
+		assert.Equal(v.res, normKingdom(v.kingdom), v.msg)
+	}
+}
+
+func TestReadKingdoms(t *testing.T) {
+	assert := assert.New(t)
+	sys := sysMock{kingdoms: []string{
+		"Morus,Plantae", "Morus,Metazoa", "Morus,Animalia",
+		"Aédes,Animalia", "Poa,", "broken",
+	}}
+	res, err := readKingdoms(context.Background(), sys)
+	assert.Nil(err)
+	assert.Equal(map[string][]string{
+		"Morus": {"Animalia", "Plantae"},
+		"Aédes": {"Animalia"},
+	}, res)
+	assert.Equal(
+		map[string]int{"Morus": 2, "Aédes": 1}, kingdomCounts(res),
+	)
+}
@@ -0,0 +1,113 @@
+package ent
+
+import (
+	"strings"
+	"unicode"
+)
+
+// rankMarkers contains rank markers and qualifiers that can appear in
+// names. The values are normalized forms of the markers.
+var rankMarkers = map[string]string{
+	"subsp.":      "subsp.",
+	"ssp.":        "subsp.",
+	"var.":        "var.",
+	"subvar.":     "subvar.",
+	"f.":          "f.",
+	"fo.":         "f.",
+	"forma":       "f.",
+	"subf.":       "subf.",
+	"morph.":      "morph.",
+	"nothosubsp.": "nothosubsp.",
+	"nothovar.":   "nothovar.",
+	"sp.":         "sp.",
+	"spp.":        "spp.",
+	"cf.":         "cf.",
+	"aff.":        "aff.",
+	"nr.":         "nr.",
+}
+
+// Reasons for rejection of names during normalization.
+const (
+	rejectEmpty     = "empty"
+	rejectUppercase = "uppercase"
+	rejectNonLetter = "non-letter"
+)
+
+// normName is a name after normalization.
+type normName struct {
+	// name is the normalized name without rank markers and rejected tokens.
+	name string
+	// markers are the rank markers that were removed from the name.
+	markers []string
+	// rejected are tokens that were removed from the name.
+	rejected []rejectedToken
+}
+
+// rejectedToken is a token of a name that cannot be a part of a canonical
+// form, usually an author or a year.
+type rejectedToken struct {
+	token, reason string
+}
+
+// normalize removes rank markers from a name and checks that the rest of
+// the name looks like a clean canonical form. The name is converted to
+// the Unicode NFC form. Tokens after the first word with uppercase letters
+// or non-letter characters are removed and kept as rejected tokens. If the
+// whole name is rejected, because it is empty or its first word is not
+// a word of letters, it returns the reason of the rejection.
+func normalize(name string) (normName, string) {
+	var res normName
+	words := strings.Fields(nfc(name))
+	if len(words) == 0 {
+		return res, rejectEmpty
+	}
+
+	if !isNameWord(words[0]) {
+		return res, rejectNonLetter
+	}
+
+	clean := []string{words[0]}
+	for _, v := range words[1:] {
+		if m, ok := rankMarkers[strings.ToLower(v)]; ok {
+			res.markers = append(res.markers, m)
+			continue
+		}
+		if hasUpper(v) {
+			res.rejected = append(res.rejected, rejectedToken{v, rejectUppercase})
+			continue
+		}
+		if !isNameWord(v) {
+			res.rejected = append(res.rejected, rejectedToken{v, rejectNonLetter})
+			continue
+		}
+		clean = append(clean, v)
+	}
+
+	res.name = strings.Join(clean, " ")
+	return res, ""
+}
+
+// isNameWord checks that a word consists of letters. Hyphens are allowed
+// between letters, as in 'novae-angliae'.
+func isNameWord(w string) bool {
+	rs := []rune(w)
+	for i, v := range rs {
+		if unicode.IsLetter(v) {
+			continue
+		}
+		if v == '-' && i > 0 && i < len(rs)-1 && rs[i-1] != '-' {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func hasUpper(w string) bool {
+	for _, v := range w {
+		if unicode.IsUpper(v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package ent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		msg, name, res string
+		markers        []string
+		rejected       []rejectedToken
+		reason         string
+	}{
+		{"empty", "  ", "", nil, nil, rejectEmpty},
+		{"bad first word", "1758 bus", "", nil, nil, rejectNonLetter},
+		{"uninomial", "Bacteria", "Bacteria", nil, nil, ""},
+		{"binomial", "Aus bus", "Aus bus", nil, nil, ""},
+		{"hyphen", "Aster novae-angliae", "Aster novae-angliae", nil, nil, ""},
+		{"nfc", "Aus béus", "Aus béus", nil, nil, ""},
+		{"marker", "Aus bus var. cus", "Aus bus cus", []string{"var."}, nil, ""},
+		{"marker synonym", "Aus bus ssp. cus", "Aus bus cus",
+			[]string{"subsp."}, nil, ""},
+		{"author", "Aus bus Linnaeus", "Aus bus", nil,
+			[]rejectedToken{{"Linnaeus", rejectUppercase}}, ""},
+		{"author and year", "Aus bus L. 1758", "Aus bus", nil,
+			[]rejectedToken{
+				{"L.", rejectUppercase}, {"1758", rejectNonLetter},
+			}, ""},
+		{"token in the middle", "Aus bus (L.) cus", "Aus bus cus", nil,
+			[]rejectedToken{{"(L.)", rejectUppercase}}, ""},
+		{"digit", "Aus bus2 cus", "Aus cus", nil,
+			[]rejectedToken{{"bus2", rejectNonLetter}}, ""},
+		{"bad hyphens", "Aus -bus cus--dus", "Aus", nil,
+			[]rejectedToken{
+				{"-bus", rejectNonLetter}, {"cus--dus", rejectNonLetter},
+			}, ""},
+		{"all together", "Aus bus subsp. cus Smith, 1900", "Aus bus cus",
+			[]string{"subsp."},
+			[]rejectedToken{
+				{"Smith,", rejectUppercase}, {"1900", rejectNonLetter},
+			}, ""},
+	}
+	for _, v := range tests {
+		res, reason := normalize(v.name)
+		assert.Equal(v.reason, reason, v.msg)
+		assert.Equal(v.res, res.name, v.msg)
+		assert.Equal(v.markers, res.markers, v.msg)
+		assert.Equal(v.rejected, res.rejected, v.msg)
+	}
+}
@@ -0,0 +1,118 @@
+package ent
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gnames/gnfmt"
+)
+
+// confusion is a substitution that OCR software often makes, with
+// the probability of such a mistake.
+type confusion struct {
+	from, to string
+	prob     float64
+}
+
+// confusions are common OCR errors in scans of old biodiversity literature.
+var confusions = []confusion{
+	{"rn", "m", 0.3},
+	{"m", "rn", 0.3},
+	{"c", "e", 0.25},
+	{"e", "c", 0.25},
+	{"ii", "u", 0.2},
+	{"u", "ii", 0.2},
+	{"l", "1", 0.2},
+	{"l", "I", 0.15},
+	{"cl", "d", 0.15},
+	{"d", "cl", 0.15},
+	{"li", "h", 0.15},
+	{"h", "li", 0.15},
+	{"i", "l", 0.1},
+	{"n", "u", 0.1},
+	{"u", "n", 0.1},
+	{"h", "b", 0.1},
+	{"b", "h", 0.1},
+	{"vv", "w", 0.1},
+	{"w", "vv", 0.1},
+}
+
+// ocrVariant is a misspelling of a dictionary word, that is likely to
+// appear after OCR.
+type ocrVariant struct {
+	word  string
+	score float64
+}
+
+// ocr saves OCR variants of the words from in/genera.csv and
+// in/species.csv to the 'ocr' directory. Rows have 'variant,word,score'
+// format. Variants that coincide with dictionary words or common words are
+// ignored. If the same variant comes from several words, only the most
+// likely one is kept.
+func (o *Output) ocr() error {
+	for _, v := range []string{"genera", "species"} {
+		res := o.ocrVariants(o.inWords[v])
+		err := o.saveStrings("ocr/"+v+".csv", res)
+		if err != nil {
+			err = fmt.Errorf("-> o.saveStrings: %w", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *Output) ocrVariants(words []string) []string {
+	vars := make(map[string]ocrVariant)
+	for _, word := range words {
+		for k, v := range ocrMisspell(word) {
+			if v < o.cfg.OCRMinScore {
+				continue
+			}
+			if _, ok := o.words[k]; ok {
+				continue
+			}
+			if _, ok := o.dat.Common[strings.ToLower(k)]; ok {
+				continue
+			}
+			if old, ok := vars[k]; ok &&
+				(old.score > v || old.score == v && old.word < word) {
+				continue
+			}
+			vars[k] = ocrVariant{word: word, score: v}
+		}
+	}
+
+	res := make([]string, 0, len(vars))
+	for k, v := range vars {
+		row := gnfmt.ToCSV(
+			[]string{k, v.word, strconv.FormatFloat(v.score, 'f', 2, 64)}, ',',
+		)
+		res = append(res, row)
+	}
+	slices.Sort(res)
+	return res
+}
+
+// ocrMisspell returns variants of a word with one OCR confusion, together
+// with the probability of the confusion.
+func ocrMisspell(word string) map[string]float64 {
+	res := make(map[string]float64)
+	for _, c := range confusions {
+		start := 0
+		for {
+			idx := strings.Index(word[start:], c.from)
+			if idx < 0 {
+				break
+			}
+			idx += start
+			v := word[:idx] + c.to + word[idx+len(c.from):]
+			if v != word && res[v] < c.prob {
+				res[v] = c.prob
+			}
+			start = idx + 1
+		}
+	}
+	return res
+}
@@ -0,0 +1,77 @@
+package ent
+
+import (
+	"testing"
+
+	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOCRMisspell(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		msg, word, variant string
+		prob               float64
+	}{
+		{"rn to m", "cornus", "comus", 0.3},
+		{"m to rn", "Amanita", "Arnanita", 0.3},
+		{"uppercase is not confused", "Clavaria", "Davaria", 0},
+		{"cl to d", "inclusa", "indusa", 0.15},
+		{"d to cl", "nodosa", "noclosa", 0.15},
+		{"li to h", "alium", "ahum", 0.15},
+		{"h to li", "Ophrys", "Oplirys", 0.15},
+		{"h to b", "Ophrys", "Opbrys", 0.1},
+		{"ii to u", "fabii", "fabu", 0.2},
+		{"u to ii", "rubra", "riibra", 0.2},
+		{"l to 1", "alba", "a1ba", 0.2},
+		{"c to e", "acer", "aeer", 0.25},
+		{"every position", "rubus", "rubiis", 0.2},
+		{"vv to w", "vvalkeri", "walkeri", 0.1},
+	}
+	for _, v := range tests {
+		res := ocrMisspell(v.word)
+		assert.Equal(v.prob, res[v.variant], v.msg)
+		assert.NotContains(res, v.word, v.msg)
+	}
+
+	// the most likely confusion wins for the same variant.
+	res := ocrMisspell("nun")
+	assert.Equal(0.1, res["uun"])
+	assert.Equal(0.2, res["niin"])
+	assert.Empty(ocrMisspell("xyz"))
+}
+
+func TestOCRVariants(t *testing.T) {
+	assert := assert.New(t)
+	dat := &data.Data{Common: map[string]struct{}{"comus": {}}}
+	tests := []struct {
+		msg      string
+		minScore float64
+		words    []string
+		res      []string
+	}{
+		{"limit", 0.25, []string{"acer"},
+			[]string{"accr,acer,0.25", "aeer,acer,0.25"}},
+		{"common word", 0.3, []string{"cornus"}, []string{}},
+		{"dictionary word", 0.1, []string{"iris", "lris"},
+			[]string{
+				"1ris,lris,0.20", "Iris,lris,0.15", "irls,iris,0.10", "lrls,lris,0.10",
+			}},
+		{"most likely source", 0.3, []string{"arnus", "amns"},
+			[]string{"amus,arnus,0.30", "arnns,amns,0.30"}},
+		{"tie", 0.3, []string{"rnm", "mrn"},
+			[]string{"mm,mrn,0.30", "rnrn,mrn,0.30"}},
+	}
+	for _, v := range tests {
+		o := &Output{
+			cfg:   config.New(config.OptOCRMinScore(v.minScore)),
+			dat:   dat,
+			words: make(map[string]struct{}),
+		}
+		for _, w := range v.words {
+			o.words[w] = struct{}{}
+		}
+		assert.Equal(v.res, o.ocrVariants(v.words), v.msg)
+	}
+}
@@ -1,45 +1,108 @@
 package ent
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"slices"
 	"sort"
 	"strings"
-	"unicode"
 
 	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/gnames/gndict/internal/progress"
+	"github.com/gnames/gndict/pkg/bloom"
 	"github.com/gnames/gndict/pkg/config"
+	"github.com/gnames/gnfmt"
 	"github.com/gnames/gnsys"
+	"github.com/rs/zerolog/log"
 )
 
 type Output struct {
 	cfg   config.Config
 	sys   Sys
 	dat   *data.Data
+	st    Store
 	genSp map[string][]string
+
+	// genBucket keeps buckets of genera to classify canonical forms.
+	genBucket map[string]Bucket
+
+	// kingdoms are sorted kingdoms of genera.
+	kingdoms map[string][]string
+
+	// cl assigns words to buckets.
+	cl *classifier
+
+	// words contains all words that are in 'in' and 'in-ambig' dictionaries.
+	words map[string]struct{}
+
+	// inWords contains words of 'in' dictionaries by their category.
+	inWords map[string][]string
+
+	// files are saved dictionary files in the order of creation.
+	files []string
+
+	// counts are the numbers of entries in saved dictionary files.
+	counts map[string]int
+
+	// skipped are the numbers of words that did not make it to the
+	// dictionary by the reason.
+	skipped map[string]int
+
+	// stored keeps lowercase names saved to Store tables that have
+	// blacklists, so blacklisted words are saved to these tables once.
+	stored map[string]map[string]struct{}
 }
 
-func NewOutput(cfg config.Config, sys Sys, dat *data.Data) (*Output, error) {
+// NewOutput creates an Output instance. If st is not nil, dictionary records
+// are also saved to the Store.
+func NewOutput(
+	cfg config.Config,
+	sys Sys,
+	dat *data.Data,
+	st Store,
+) (*Output, error) {
 	res := &Output{
-		cfg: cfg,
-		sys: sys,
-		dat: dat,
+		cfg:       cfg,
+		sys:       sys,
+		dat:       dat,
+		st:        st,
+		genBucket: make(map[string]Bucket),
+		words:     make(map[string]struct{}),
+		inWords:   make(map[string][]string),
+		counts:    make(map[string]int),
+		skipped:   make(map[string]int),
+		stored: map[string]map[string]struct{}{
+			"species":    make(map[string]struct{}),
+			"uninomials": make(map[string]struct{}),
+		},
 	}
 
-	dictDir := filepath.Join(cfg.CacheDir, "dict")
-	err := gnsys.MakeDir(dictDir)
+	var err error
+	res.cl, err = newClassifier(cfg, dat)
 	if err != nil {
-		err = fmt.Errorf("-> gnsys.MakeDir: %w", err)
+		err = fmt.Errorf("-> newClassifier: %w", err)
 		return nil, err
 	}
-	err = gnsys.CleanDir(dictDir)
+
+	dictDir := cfg.BuildDir()
+	if ok, _, _ := gnsys.DirExists(dictDir); ok {
+		return nil, fmt.Errorf("%w: %s", ErrBuildExists, dictDir)
+	}
+	err = gnsys.MakeDir(dictDir)
 	if err != nil {
-		err = fmt.Errorf("-> gnsys.CleanDir: %w", err)
+		err = fmt.Errorf("-> gnsys.MakeDir: %w", err)
 		return nil, err
 	}
-	for _, v := range []string{"common", "in", "in-ambig", "not-in"} {
+	dirs := []string{"common", "in", "in-ambig", "not-in"}
+	if cfg.ASCIIFold {
+		dirs = append(dirs, "alt")
+	}
+	if cfg.OCR {
+		dirs = append(dirs, "ocr")
+	}
+	for _, v := range dirs {
 		path := filepath.Join(dictDir, v)
 		err = gnsys.MakeDir(path)
 		if err != nil {
@@ -51,53 +114,139 @@ func NewOutput(cfg config.Config, sys Sys, dat *data.Data) (*Output, error) {
 	return res, nil
 }
 
-func (o *Output) Create() error {
-	err := o.uninomials()
+// Entries returns saved dictionary files in the order of creation with
+// the number of entries in every file.
+func (o *Output) Entries() ([]string, map[string]int) {
+	return o.files, o.counts
+}
+
+// Create saves dictionaries to the BuildDir. If ctx is canceled or
+// creation fails, the build directory is removed. The current dictionary
+// is not changed by Create.
+func (o *Output) Create(ctx context.Context) error {
+	err := o.create(ctx)
+	if err != nil {
+		e := os.RemoveAll(o.cfg.BuildDir())
+		if e != nil {
+			log.Warn().Err(e).Msg("Cannot remove partial dictionaries")
+		}
+		return err
+	}
+	return nil
+}
+
+func (o *Output) create(ctx context.Context) error {
+	var err error
+	if o.st != nil {
+		err = o.st.Init()
+		if err != nil {
+			err = fmt.Errorf("-> st.Init: %w", err)
+			return err
+		}
+	}
+
+	// kingdoms of genera are a part of the ambiguity score.
+	o.kingdoms, err = readKingdoms(ctx, o.sys)
+	if err != nil {
+		err = fmt.Errorf("-> readKingdoms: %w", err)
+		return err
+	}
+	o.cl.sc.setKingdoms(kingdomCounts(o.kingdoms))
+
+	err = o.uninomials(ctx)
 	if err != nil {
 		err = fmt.Errorf("-> o.uninomials: %w", err)
 		return err
 	}
-	err = o.genera()
+	err = o.genera(ctx)
 	if err != nil {
 		err = fmt.Errorf("-> o.genera: %w", err)
 		return err
 	}
-	err = o.species()
+	err = o.species(ctx)
 	if err != nil {
 		err = fmt.Errorf("-> o.species: %w", err)
 		return err
 	}
 
+	if o.cfg.GenderVariants {
+		err = o.speciesDerived(ctx)
+		if err != nil {
+			err = fmt.Errorf("-> o.speciesDerived: %w", err)
+			return err
+		}
+	}
+
+	err = o.homonyms()
+	if err != nil {
+		err = fmt.Errorf("-> o.homonyms: %w", err)
+		return err
+	}
+
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	if o.cfg.OCR {
+		err = o.ocr()
+		if err != nil {
+			err = fmt.Errorf("-> o.ocr: %w", err)
+			return err
+		}
+	}
+
 	err = o.fromData()
 	if err != nil {
 		err = fmt.Errorf("-> o.fromData: %w", err)
 		return err
 	}
 
+	if o.st != nil {
+		err = o.canonicals(ctx)
+		if err != nil {
+			err = fmt.Errorf("-> o.canonicals: %w", err)
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (o *Output) uninomials() error {
+func (o *Output) uninomials(ctx context.Context) error {
 	var white, grey []string
-	lines, err := o.sys.ReadFile("uninomials.csv")
+	var recs []Record
+	lines, err := o.sys.ReadFile(ctx, "uninomials.csv")
 	if err != nil {
 		return err
 	}
 
+	bar := progress.New(ctx, "output uninomials", len(lines))
 	for _, v := range lines {
-		name, _, _ := strings.Cut(v, ",")
-		if o.uninomialProblems(name) {
-			continue
-		}
-		if o.isGreyWord(name) {
-			grey = append(grey, v)
-		} else {
-			white = append(white, v)
+		bar.Increment()
+		rec, row := o.cl.classify("uninomials", v)
+		switch rec.Bucket {
+		case In:
+			white = append(white, row)
+		case InAmbig:
+			grey = append(grey, row)
 		}
+		recs = append(recs, rec)
 	}
 	for _, v := range [][]string{white, grey} {
 		slices.Sort(v)
 	}
+	bar.Finish()
+	o.addWords("uninomials", recs)
+	err = o.saveAlts("uninomials.csv", recs)
+	if err != nil {
+		err = fmt.Errorf("-> o.saveAlts: %w", err)
+		return err
+	}
+	err = o.saveRecords("uninomials", recs)
+	if err != nil {
+		err = fmt.Errorf("-> o.saveRecords: %w", err)
+		return err
+	}
 	return o.saveUniOrSp(white, grey, "uninomials.csv")
 
 }
@@ -118,26 +267,41 @@ func (o *Output) saveUniOrSp(white, grey []string, file string) error {
 	return nil
 }
 
-func (o *Output) genera() error {
+func (o *Output) genera(ctx context.Context) error {
 	var white, grey, greySp []string
-	lines, err := o.sys.ReadFile("genera.csv")
+	var recs []Record
+	lines, err := o.sys.ReadFile(ctx, "genera.csv")
 	if err != nil {
 		err = fmt.Errorf("-> sys.ReadFile: %w", err)
 		return err
 	}
 
+	bar := progress.New(ctx, "output genera", len(lines))
 	for _, v := range lines {
-		name, _, _ := strings.Cut(v, ",")
-		if o.uninomialProblems(name) {
-			continue
-		}
-		if o.isGreyWord(name) {
-			grey = append(grey, v)
-		} else {
-			white = append(white, v)
+		bar.Increment()
+		rec, row := o.cl.classify("genera", v)
+		switch rec.Bucket {
+		case In:
+			white = append(white, row)
+		case InAmbig:
+			grey = append(grey, row)
 		}
+		o.genBucket[rec.Name] = rec.Bucket
+		recs = append(recs, rec)
 	}
-	greySp, err = o.greySpecies(grey)
+	bar.Finish()
+	o.addWords("genera", recs)
+	err = o.saveAlts("genera.csv", recs)
+	if err != nil {
+		err = fmt.Errorf("-> o.saveAlts: %w", err)
+		return err
+	}
+	err = o.saveRecords("genera", recs)
+	if err != nil {
+		err = fmt.Errorf("-> o.saveRecords: %w", err)
+		return err
+	}
+	greySp, err = o.greySpecies(ctx, grey)
 	if err != nil {
 		err = fmt.Errorf("-> o.greySpecies: %w", err)
 		return err
@@ -148,19 +312,30 @@ func (o *Output) genera() error {
 	return o.saveGen(white, grey, greySp)
 }
 
-func (o *Output) greySpecies(grey []string) ([]string, error) {
+func (o *Output) greySpecies(
+	ctx context.Context,
+	grey []string,
+) ([]string, error) {
 	gSp := make(map[string]map[string]struct{})
 	for _, v := range grey {
 		name, _, _ := strings.Cut(v, ",")
 		gSp[name] = make(map[string]struct{})
 	}
-	names, err := o.sys.Canonicals()
+	names, err := o.sys.Canonicals(ctx)
 	if err != nil {
 		err = fmt.Errorf("-> sys.Canonicals: %w", err)
 		return nil, err
 	}
 
-	for _, v := range names {
+	bar := progress.New(ctx, "output genera_species", len(names))
+	defer bar.Finish()
+	for i, v := range names {
+		if i%checkEvery == 0 {
+			if err = ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		bar.Increment()
 		words := strings.Split(v, " ")
 		if len(words) < 2 {
 			continue
@@ -219,75 +394,155 @@ func (o *Output) saveGen(white, grey, greySp []string) error {
 	return nil
 }
 
-func (o *Output) species() error {
+func (o *Output) species(ctx context.Context) error {
 	var white, grey []string
-	lines, err := o.sys.ReadFile("species.csv")
+	var recs []Record
+	lines, err := o.sys.ReadFile(ctx, "species.csv")
 	if err != nil {
 		return err
 	}
 
+	bar := progress.New(ctx, "output species", len(lines))
 	for _, v := range lines {
-		name, _, _ := strings.Cut(v, ",")
-		if o.speciesProblems(name) {
-			continue
-		}
-
-		if o.isGreyWord(name) {
-			grey = append(grey, v)
-		} else {
-			white = append(white, v)
+		bar.Increment()
+		rec, row := o.cl.classify("species", v)
+		switch rec.Bucket {
+		case In:
+			white = append(white, row)
+		case InAmbig:
+			grey = append(grey, row)
 		}
-
+		recs = append(recs, rec)
+	}
+	bar.Finish()
+	o.addWords("species", recs)
+	err = o.saveAlts("species.csv", recs)
+	if err != nil {
+		err = fmt.Errorf("-> o.saveAlts: %w", err)
+		return err
 	}
 
 	for _, v := range [][]string{white, grey} {
 		sort.Strings(v)
 	}
+	err = o.saveRecords("species", recs)
+	if err != nil {
+		err = fmt.Errorf("-> o.saveRecords: %w", err)
+		return err
+	}
 	return o.saveUniOrSp(white, grey, "species.csv")
 }
 
-func (o *Output) uninomialProblems(word string) bool {
-	var res bool
-	word = strings.ToLower(word)
-	if _, ok := o.dat.UniBlack[word]; ok {
-		res = true
+// speciesDerived saves gender variants of epithets to separate
+// species_derived.csv files. Counts of these words are the counts of
+// the epithets they were derived from.
+func (o *Output) speciesDerived(ctx context.Context) error {
+	var white, grey []string
+	var recs []Record
+	lines, err := o.sys.ReadFile(ctx, "species_derived.csv")
+	if err != nil {
+		return err
+	}
+
+	for _, v := range lines {
+		rec, row := o.cl.classify("species", v)
+		rec.Derived = true
+		switch rec.Bucket {
+		case In:
+			white = append(white, row)
+		case InAmbig:
+			grey = append(grey, row)
+		}
+		if rec.Bucket != NotIn {
+			o.words[rec.Name] = struct{}{}
+		}
+		recs = append(recs, rec)
 	}
 
-	if strings.Contains(word, ".") {
-		res = true
+	for _, v := range [][]string{white, grey} {
+		sort.Strings(v)
+	}
+	err = o.saveRecords("species", recs)
+	if err != nil {
+		err = fmt.Errorf("-> o.saveRecords: %w", err)
+		return err
 	}
-	return res
+	return o.saveUniOrSp(white, grey, "species_derived.csv")
 }
 
-func (o *Output) speciesProblems(sp string) bool {
-	spLow := strings.ToLower(sp)
-	if _, ok := o.dat.SpBlack[spLow]; ok {
-		return true
+// canonicals saves canonical forms to the Store. Canonicals inherit
+// the bucket of their genus.
+func (o *Output) canonicals(ctx context.Context) error {
+	names, err := o.sys.Canonicals(ctx)
+	if err != nil {
+		err = fmt.Errorf("-> sys.Canonicals: %w", err)
+		return err
 	}
 
-	if len(sp) < 2 || strings.Contains(sp, ".") {
-		return true
+	recs := make([]Record, 0, len(names))
+	for _, v := range names {
+		gen, _, _ := strings.Cut(v, " ")
+		rec := Record{Name: v, Bucket: In}
+		switch o.genBucket[gen] {
+		case InAmbig:
+			rec.Bucket, rec.GreyReason = InAmbig, "genus"
+		case NotIn:
+			rec.Bucket, rec.BlackHit = NotIn, "genus"
+		}
+		recs = append(recs, rec)
 	}
+	return o.saveRecords("canonicals", recs)
+}
 
-	for _, v := range sp {
-		if unicode.IsDigit(v) {
-			return true
+// addWords remembers words that made it to the dictionary and counts
+// words that did not.
+func (o *Output) addWords(category string, recs []Record) {
+	for _, v := range recs {
+		if v.Bucket == NotIn {
+			reason, _, _ := strings.Cut(v.BlackHit, ":")
+			o.skipped[reason]++
+		}
+		if v.Bucket != In && v.Bucket != InAmbig {
+			continue
+		}
+		o.words[v.Name] = struct{}{}
+		if v.Bucket == In {
+			o.inWords[category] = append(o.inWords[category], v.Name)
 		}
 	}
-	return false
 }
 
-func (o *Output) isGreyWord(word string) bool {
-	var res bool
-	if len(word) < 4 {
-		res = true
+// saveAlts saves ASCII variants of words with diacritics and ligatures
+// to the 'alt' directory, if ASCIIFold option is set. The rows have
+// 'variant,word' format.
+func (o *Output) saveAlts(file string, recs []Record) error {
+	if !o.cfg.ASCIIFold {
+		return nil
 	}
-	word = strings.ToLower(word)
-	if _, ok := o.dat.Common[word]; ok {
-		res = true
+	var res []string
+	for _, v := range recs {
+		if v.Bucket == NotIn {
+			continue
+		}
+		for _, alt := range asciiFold(v.Name) {
+			res = append(res, gnfmt.ToCSV([]string{alt, v.Name}, ','))
+		}
 	}
+	slices.Sort(res)
+	return o.saveStrings("alt/"+file, res)
+}
 
-	return res
+// saveRecords sends records to the Store, if it is set.
+func (o *Output) saveRecords(table string, recs []Record) error {
+	if o.st == nil {
+		return nil
+	}
+	if names, ok := o.stored[table]; ok {
+		for _, v := range recs {
+			names[strings.ToLower(v.Name)] = struct{}{}
+		}
+	}
+	return o.st.Save(table, recs)
 }
 
 func (o *Output) fromData() error {
@@ -316,6 +571,32 @@ func (o *Output) fromData() error {
 		sort.Strings(v)
 	}
 
+	for _, v := range []struct {
+		table, hit string
+		bucket     Bucket
+		words      []string
+	}{
+		{"common", "", Common, com},
+		{"species", "species-black", NotIn, blkSp},
+		{"uninomials", "uninomials-black", NotIn, blkUni},
+	} {
+		// blacklisted words that were classified are already saved with
+		// their black_hit.
+		stored := o.stored[v.table]
+		recs := make([]Record, 0, len(v.words))
+		for _, w := range v.words {
+			if _, ok := stored[w]; ok {
+				continue
+			}
+			recs = append(recs, Record{Name: w, Bucket: v.bucket, BlackHit: v.hit})
+		}
+		err := o.saveRecords(v.table, recs)
+		if err != nil {
+			err = fmt.Errorf("-> o.saveRecords: %w", err)
+			return err
+		}
+	}
+
 	return o.saveFromData(com, blkSp, blkUni)
 }
 
@@ -344,9 +625,10 @@ func (o *Output) saveFromData(com, blkSp, blkUni []string) error {
 func (o *Output) saveStrings(path string, data []string) error {
 	var f *os.File
 	var err error
-	f, err = os.Create(
-		filepath.Join(o.cfg.CacheDir, "dict", path),
-	)
+	o.files = append(o.files, path)
+	o.counts[path] = len(data)
+	path = filepath.Join(o.cfg.BuildDir(), path)
+	f, err = os.Create(path)
 	if err != nil {
 		err = fmt.Errorf("-> os.Create: %w", err)
 		return err
@@ -360,5 +642,29 @@ func (o *Output) saveStrings(path string, data []string) error {
 		err = fmt.Errorf("-> WriteString: %w", err)
 		return err
 	}
+
+	if o.cfg.Bloom {
+		err = o.saveBloom(path, data)
+		if err != nil {
+			err = fmt.Errorf("-> o.saveBloom: %w", err)
+			return err
+		}
+	}
 	return nil
 }
+
+// saveBloom creates a Bloom filter sidecar file for a dictionary file.
+// Only the first field of each CSV row (the word or name) goes to
+// the filter.
+func (o *Output) saveBloom(path string, data []string) error {
+	bf := bloom.New(len(data), o.cfg.BloomFPRate)
+	for _, v := range data {
+		name, _, _ := strings.Cut(v, ",")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		bf.Add(name)
+	}
+	return bf.Save(path + bloom.Ext)
+}
@@ -0,0 +1,66 @@
+package ent
+
+import (
+	"testing"
+
+	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storeMock keeps saved records in memory.
+type storeMock struct {
+	tables map[string][]Record
+}
+
+func (s *storeMock) Init() error {
+	s.tables = make(map[string][]Record)
+	return nil
+}
+
+func (s *storeMock) Save(table string, recs []Record) error {
+	s.tables[table] = append(s.tables[table], recs...)
+	return nil
+}
+
+func (s *storeMock) Close() error {
+	return nil
+}
+
+func TestFromDataStoresOnce(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	cfg := config.New(config.OptCacheDir(t.TempDir()))
+	dat := data.New()
+	dat.SpBlack = map[string]struct{}{"alb": {}, "sp": {}}
+	dat.UniBlack = map[string]struct{}{"incertae": {}}
+	st := &storeMock{}
+	require.Nil(st.Init())
+	o, err := NewOutput(cfg, sysMock{}, dat, st)
+	require.Nil(err)
+
+	// classified blacklisted words are saved with their black_hit.
+	err = o.saveRecords("species", []Record{
+		{Name: "alba", Bucket: In},
+		{Name: "alb", Bucket: NotIn, BlackHit: "blacklist"},
+	})
+	require.Nil(err)
+	err = o.saveRecords("uninomials", []Record{
+		{Name: "Incertae", Bucket: NotIn, BlackHit: "blacklist"},
+	})
+	require.Nil(err)
+	require.Nil(o.fromData())
+
+	assert.Equal([]Record{
+		{Name: "alba", Bucket: In},
+		{Name: "alb", Bucket: NotIn, BlackHit: "blacklist"},
+		{Name: "sp", Bucket: NotIn, BlackHit: "species-black"},
+	}, st.tables["species"])
+	assert.Equal([]Record{
+		{Name: "Incertae", Bucket: NotIn, BlackHit: "blacklist"},
+	}, st.tables["uninomials"])
+	// dictionary files still have all words of blacklists.
+	assert.Equal(2, o.counts["not-in/species.csv"])
+	assert.Equal(1, o.counts["not-in/uninomials.csv"])
+}
@@ -0,0 +1,67 @@
+package ent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/gnames/gndict/pkg/config"
+)
+
+// PatternMatch is a dictionary word that matches a pattern.
+type PatternMatch struct {
+	// File is the dictionary file of the word, for example 'in/genera.csv'.
+	File string
+	// Word is the matched word.
+	Word string
+}
+
+// patternFiles are dictionary files that are checked by patterns.
+var patternFiles = []string{
+	"in/uninomials.csv",
+	"in/genera.csv",
+	"in/species.csv",
+	"in-ambig/uninomials.csv",
+	"in-ambig/genera.csv",
+	"in-ambig/species.csv",
+}
+
+// MatchPattern finds words of the current dictionary that match a pattern.
+// It allows to test a pattern before it is added to the blacklist.
+func MatchPattern(cfg config.Config, src string) ([]PatternMatch, error) {
+	p, err := data.NewPattern(src)
+	if err != nil {
+		err = fmt.Errorf("-> data.NewPattern: %w", err)
+		return nil, err
+	}
+
+	var res []PatternMatch
+	for _, file := range patternFiles {
+		bs, err := os.ReadFile(filepath.Join(cfg.DictDir(), file))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, v := range strings.Split(string(bs), "\n") {
+			word, _, _ := strings.Cut(v, ",")
+			if word == "" || !p.Match(strings.ToLower(word)) {
+				continue
+			}
+			res = append(res, PatternMatch{File: file, Word: word})
+		}
+	}
+	return res, nil
+}
+
+// readPatterns reads patterns from a file.
+func readPatterns(path string) ([]data.Pattern, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return data.ParsePatterns(string(bs))
+}
@@ -1,15 +1,19 @@
 package ent
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"io"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/gnames/gndict/internal/compress"
 	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/gnames/gndict/internal/progress"
 	"github.com/gnames/gndict/pkg/config"
 	"github.com/gnames/gnfmt"
+	"github.com/rs/zerolog/log"
 )
 
 type Preproc struct {
@@ -19,21 +23,55 @@ type Preproc struct {
 	names                       []string
 	genMap, canonical           map[string]struct{}
 	uninomials, genera, species map[string]int
+
+	// sources keeps the largest number of data sources that use a word.
+	sources map[string]int
+
+	// markers keeps rank markers removed from names during normalization.
+	markers map[string][]string
+
+	// rejected keeps names with tokens that failed normalization and
+	// the reason.
+	rejected [][]string
+
+	// derived keeps gender variants of epithets with the counts of the
+	// epithets they were derived from.
+	derived map[string]int
+
+	// hybrids is the number of skipped hybrid formulas.
+	hybrids int
 }
 
-func NewPreproc(cfg config.Config, sys Sys, dat *data.Data) (*Preproc, error) {
-	names, err := sys.Names()
+// preprocFiles are the files created by Preprocess.
+var preprocFiles = []string{
+	"uninomials.csv", "genera.csv", "species.csv", "species_derived.csv",
+	"canonicals.csv", "rejected.csv", "markers.csv", "stats.csv",
+}
+
+// checkEvery is the number of processed rows between checks of the
+// context in long loops.
+const checkEvery = 10_000
+
+func NewPreproc(
+	ctx context.Context,
+	cfg config.Config,
+	sys Sys,
+	dat *data.Data,
+) (*Preproc, error) {
+	names, err := sys.Names(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	genera, err := sys.Genera()
+	genera, err := sys.Genera(ctx)
 	if err != nil {
 		return nil, err
 	}
 	genMap := make(map[string]struct{})
 	for i := range genera {
-		genMap[genera[i]] = struct{}{}
+		// genera of data sources can be in NFD, words they are compared
+		// to are in NFC.
+		genMap[nfc(strings.TrimSpace(genera[i]))] = struct{}{}
 	}
 	res := Preproc{
 		cfg:        cfg,
@@ -45,19 +83,85 @@ func NewPreproc(cfg config.Config, sys Sys, dat *data.Data) (*Preproc, error) {
 		genera:     make(map[string]int),
 		species:    make(map[string]int),
 		canonical:  make(map[string]struct{}),
+		sources:    make(map[string]int),
+		markers:    make(map[string][]string),
+		derived:    make(map[string]int),
 	}
 	return &res, nil
 }
 
-func (p *Preproc) Preprocess() error {
+// Preprocess creates files with uninomials, genera, species and canonical
+// forms from downloaded names. If ctx is canceled or preprocessing fails,
+// partially created files are removed.
+func (p *Preproc) Preprocess(ctx context.Context) error {
+	err := p.preprocess(ctx)
+	if err != nil {
+		p.cleanup()
+		return err
+	}
+	return nil
+}
+
+// cleanup removes files created by Preprocess.
+func (p *Preproc) cleanup() {
+	for _, v := range preprocFiles {
+		path := filepath.Join(p.cfg.WorkDir(), v)
+		err := compress.Remove(path)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Cannot remove %s", path)
+		}
+	}
+}
+
+// create creates a file in the WorkDir compressed according to the
+// Compression setting.
+func (p *Preproc) create(file string) (io.WriteCloser, error) {
+	path := filepath.Join(p.cfg.WorkDir(), file)
+	return compress.Create(path, p.cfg.Compression)
+}
+
+func (p *Preproc) preprocess(ctx context.Context) error {
 	var err error
-	for _, v := range p.names {
+	bar := progress.New(ctx, "preprocess names", len(p.names))
+	for i, v := range p.names {
+		if i%checkEvery == 0 {
+			if err = ctx.Err(); err != nil {
+				bar.Finish()
+				return err
+			}
+		}
+		bar.Increment()
 		if strings.ContainsRune(v, '×') {
+			p.hybrids++
+			continue
+		}
+		name, src, _ := strings.Cut(v, ",")
+		sources, _ := strconv.Atoi(src)
+		nn, reason := normalize(name)
+		if reason != "" {
+			token, _, _ := strings.Cut(strings.TrimSpace(name), " ")
+			p.rejected = append(p.rejected, []string{name, token, reason})
 			continue
 		}
-		p.words(v)
+		for _, t := range nn.rejected {
+			p.rejected = append(p.rejected, []string{name, t.token, t.reason})
+		}
+		if len(nn.markers) > 0 {
+			p.markers[nn.name] = append(p.markers[nn.name], nn.markers...)
+		}
+		p.words(nn.name, sources)
 	}
+	bar.Finish()
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	log.Info().Msgf(
+		"Rejected %d names or tokens during normalization", len(p.rejected),
+	)
 	p.cleanupUni()
+	if p.cfg.GenderVariants {
+		p.genderVariants()
+	}
 
 	err = p.makeCSV(p.uninomials, "uninomials.csv")
 	if err != nil {
@@ -75,49 +179,136 @@ func (p *Preproc) Preprocess() error {
 		return err
 	}
 
+	if p.cfg.GenderVariants {
+		err = p.makeCSV(p.derived, "species_derived.csv")
+		if err != nil {
+			err := fmt.Errorf("-> p.makeCSV: %w", err)
+			return err
+		}
+	}
+
 	err = p.makeCanonicals()
 	if err != nil {
 		err := fmt.Errorf("-> p.makeCanonicals: %w", err)
 		return err
 	}
 
+	err = p.makeRejected()
+	if err != nil {
+		err := fmt.Errorf("-> p.makeRejected: %w", err)
+		return err
+	}
+
+	err = p.makeMarkers()
+	if err != nil {
+		err := fmt.Errorf("-> p.makeMarkers: %w", err)
+		return err
+	}
+
+	err = p.makeStats()
+	if err != nil {
+		err := fmt.Errorf("-> p.makeStats: %w", err)
+		return err
+	}
+
 	return nil
 }
 
 func (p *Preproc) makeCanonicals() error {
-	path := filepath.Join(p.cfg.CacheDir, "canonicals.csv")
-	f, err := os.Create(path)
+	f, err := p.create("canonicals.csv")
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 	for k := range p.canonical {
-		_, err = f.WriteString(k + "\n")
+		_, err = io.WriteString(f, k+"\n")
 		if err != nil {
 			return err
 		}
 	}
-	return nil
+	return f.Close()
+}
+
+// makeRejected saves names with tokens that did not pass normalization and
+// the reason of rejection. Rows have 'name,token,reason' format, if the
+// whole name is rejected, the token is its first word.
+func (p *Preproc) makeRejected() error {
+	f, err := p.create("rejected.csv")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for _, v := range p.rejected {
+		_, err = io.WriteString(f, gnfmt.ToCSV(v, ',')+"\n")
+		if err != nil {
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// makeMarkers saves normalized names with rank markers that were removed
+// from them. Markers are separated by '|'.
+func (p *Preproc) makeMarkers() error {
+	f, err := p.create("markers.csv")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for k, v := range p.markers {
+		row := gnfmt.ToCSV([]string{k, strings.Join(v, "|")}, ',')
+		_, err = io.WriteString(f, row+"\n")
+		if err != nil {
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// makeStats saves the number of read names and the numbers of names
+// that were skipped during preprocessing. They are used in the run report.
+func (p *Preproc) makeStats() error {
+	f, err := p.create("stats.csv")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for _, v := range []struct {
+		key string
+		val int
+	}{
+		{"names", len(p.names)},
+		{"hybrids", p.hybrids},
+		{"rejected", len(p.rejected)},
+	} {
+		_, err = io.WriteString(f, v.key+","+strconv.Itoa(v.val)+"\n")
+		if err != nil {
+			return err
+		}
+	}
+	return f.Close()
 }
 
+// makeCSV saves words with their counts and number of data sources.
 func (p *Preproc) makeCSV(dat map[string]int, file string) error {
-	path := filepath.Join(p.cfg.CacheDir, file)
-	f, err := os.Create(path)
+	f, err := p.create(file)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 	for k, v := range dat {
-		row := gnfmt.ToCSV([]string{k, strconv.Itoa(v)}, ',')
-		_, err = f.WriteString(row + "\n")
+		row := gnfmt.ToCSV(
+			[]string{k, strconv.Itoa(v), strconv.Itoa(p.sources[k])}, ',',
+		)
+		_, err = io.WriteString(f, row+"\n")
 		if err != nil {
 			return err
 		}
 	}
-	return nil
+	return f.Close()
 }
 
-func (p *Preproc) words(s string) {
+func (p *Preproc) words(s string, sources int) {
 	words := strings.Split(s, " ")
 
 	if len(words) == 1 {
@@ -127,6 +318,10 @@ func (p *Preproc) words(s string) {
 	if len(words) > 1 {
 		p.wordsSp(words)
 	}
+
+	for _, v := range words {
+		p.sources[v] = max(p.sources[v], sources)
+	}
 }
 
 func (p *Preproc) wordsUni(s string) {
@@ -163,6 +358,28 @@ func (p *Preproc) wordsSp(words []string) {
 	p.canonical[strings.Join(words[0:idxBlkSp], " ")] = struct{}{}
 }
 
+// genderVariants creates other gender forms of specific epithets, that
+// were not found in the names. Variants that are blacklisted or are
+// common words are ignored.
+func (p *Preproc) genderVariants() {
+	for k, v := range p.species {
+		for _, vr := range genderVariants(k, p.species) {
+			if _, ok := p.species[vr]; ok {
+				continue
+			}
+			if _, ok := p.dat.SpBlack[vr]; ok {
+				continue
+			}
+			if _, ok := p.dat.Common[vr]; ok {
+				continue
+			}
+			p.derived[vr] += v
+			p.sources[vr] = max(p.sources[vr], p.sources[k])
+		}
+	}
+	log.Info().Msgf("Created %d gender variants of epithets", len(p.derived))
+}
+
 func (p *Preproc) cleanupUni() {
 	// at this point we found some genera that is not in IRMNG, move it from
 	// uninomials to genera.
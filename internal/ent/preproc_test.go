@@ -0,0 +1,76 @@
+package ent
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sysMock returns data of the cache from memory.
+type sysMock struct {
+	names, canonicals, genera, kingdoms []string
+	files                               map[string][]string
+}
+
+func (s sysMock) Names(context.Context) ([]string, error) {
+	return s.names, nil
+}
+
+func (s sysMock) Canonicals(context.Context) ([]string, error) {
+	return s.canonicals, nil
+}
+
+func (s sysMock) Genera(context.Context) ([]string, error) {
+	return s.genera, nil
+}
+
+func (s sysMock) Kingdoms(context.Context) ([]string, error) {
+	return s.kingdoms, nil
+}
+
+func (s sysMock) ReadFile(_ context.Context, path string) ([]string, error) {
+	return s.files[path], nil
+}
+
+func TestPreprocGeneraNFC(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	cfg := config.New(config.OptCacheDir(t.TempDir()))
+	// 'Aédes' with a combining acute accent (NFD).
+	sys := sysMock{genera: []string{"Ae\u0301des", " Poa "}}
+	p, err := NewPreproc(context.Background(), cfg, sys, data.New())
+	require.Nil(err)
+
+	for _, v := range []string{"A\u00e9des", "Poa", "Bacteria"} {
+		p.wordsUni(v)
+	}
+	assert.Equal(map[string]int{"A\u00e9des": 1, "Poa": 1}, p.genera)
+	assert.Equal(map[string]int{"Bacteria": 1}, p.uninomials)
+}
+
+func TestPreprocRejected(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	cfg := config.New(config.OptCacheDir(t.TempDir()))
+	require.Nil(os.MkdirAll(cfg.WorkDir(), 0755))
+	sys := sysMock{names: []string{
+		"Aus bus Linnaeus 1758,3", "1758 bus,1", "Aus cus,2",
+	}}
+	p, err := NewPreproc(context.Background(), cfg, sys, data.New())
+	require.Nil(err)
+	require.Nil(p.Preprocess(context.Background()))
+
+	assert.Equal([][]string{
+		{"Aus bus Linnaeus 1758", "Linnaeus", rejectUppercase},
+		{"Aus bus Linnaeus 1758", "1758", rejectNonLetter},
+		{"1758 bus", "1758", rejectNonLetter},
+	}, p.rejected)
+	// the rest of the name is used.
+	assert.Contains(p.species, "bus")
+	assert.Contains(p.species, "cus")
+}
@@ -0,0 +1,35 @@
+package ent
+
+// Bucket describes which part of the dictionary a word belongs to.
+type Bucket string
+
+const (
+	// In words are reliable scientific name words.
+	In Bucket = "in"
+	// InAmbig words are scientific name words that are also used in other
+	// contexts.
+	InAmbig Bucket = "in-ambig"
+	// NotIn words are not used as scientific name words.
+	NotIn Bucket = "not-in"
+	// Common words come from lists of common words of European languages.
+	Common Bucket = "common"
+)
+
+// Record contains information about one entry of a dictionary.
+type Record struct {
+	// Name is a word or a name.
+	Name string
+	// Count is the number of times the word was found in names.
+	Count int
+	// Bucket is the part of the dictionary the word belongs to.
+	Bucket Bucket
+	// GreyReason explains why the word is in the InAmbig bucket.
+	GreyReason string
+	// BlackHit explains why the word is in the NotIn bucket.
+	BlackHit string
+	// Score is the ambiguity score of the word, from 0 to 1.
+	Score float64
+	// Derived is true for words that were not found in names, but were
+	// generated from other words (for example gender variants of epithets).
+	Derived bool
+}
@@ -0,0 +1,202 @@
+package ent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// reportFile is the name of the run report. The JSON report of the current
+// build is also kept in the WorkDir to compare with the next one.
+const reportFile = "report"
+
+// Report contains statistics of a dictionary build.
+type Report struct {
+	// Date is the time of the build in RFC3339 format.
+	Date string `json:"date"`
+
+	// Scope is the taxon the dictionary was built for.
+	Scope string `json:"scope,omitempty"`
+
+	// NamesRead is the number of downloaded names.
+	NamesRead int `json:"namesRead"`
+
+	// Skipped are the numbers of names and words that did not make it to
+	// the dictionary by the reason (hybrids, blacklist, dot, digit etc).
+	Skipped map[string]int `json:"skipped"`
+
+	// Files are dictionary files in the order of creation.
+	Files []string `json:"files"`
+
+	// Entries are the numbers of entries in dictionary files.
+	Entries map[string]int `json:"entries"`
+
+	// PreviousDate is the date of the previous report, if it exists.
+	PreviousDate string `json:"previousDate,omitempty"`
+
+	// Changes are changes of entries numbers in percent compared to
+	// the previous report.
+	Changes map[string]float64 `json:"changes,omitempty"`
+
+	// Shrunk are files that shrank more than allowed by ShrinkLimits.
+	Shrunk []string `json:"shrunk,omitempty"`
+}
+
+// Report creates a report of the build. It has to be called after Create.
+func (o *Output) Report(ctx context.Context) (Report, error) {
+	res := Report{
+		Date:    time.Now().Format(time.RFC3339),
+		Scope:   o.cfg.Scope,
+		Skipped: make(map[string]int),
+		Files:   o.files,
+		Entries: o.counts,
+	}
+
+	stats, err := o.sys.ReadFile(ctx, "stats.csv")
+	if err != nil {
+		log.Warn().Err(err).Msg("Cannot read preprocessing stats")
+	}
+	for _, v := range stats {
+		k, val, _ := strings.Cut(v, ",")
+		n, _ := strconv.Atoi(val)
+		if k == "names" {
+			res.NamesRead = n
+			continue
+		}
+		res.Skipped[k] = n
+	}
+	for k, v := range o.skipped {
+		res.Skipped[k] += v
+	}
+
+	prev, err := o.previousReport()
+	if err != nil {
+		err = fmt.Errorf("-> o.previousReport: %w", err)
+		return res, err
+	}
+	if prev != nil {
+		res.compare(prev, o.cfg.ShrinkLimits)
+	}
+	return res, nil
+}
+
+// SaveReport saves the report in JSON and Markdown formats to the
+// BuildDir. If files shrank more than allowed, it returns an error. The
+// report is compared with the next run after the build is activated.
+func (o *Output) SaveReport(rep Report) error {
+	js, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(o.cfg.BuildDir(), reportFile+".json")
+	err = os.WriteFile(path, js, 0644)
+	if err != nil {
+		return err
+	}
+	path = filepath.Join(o.cfg.BuildDir(), reportFile+".md")
+	err = os.WriteFile(path, []byte(rep.Markdown()), 0644)
+	if err != nil {
+		return err
+	}
+
+	if len(rep.Shrunk) > 0 {
+		return fmt.Errorf(
+			"dictionary files shrank more than allowed: %s (see %s)",
+			strings.Join(rep.Shrunk, ", "), path,
+		)
+	}
+	return nil
+}
+
+// previousReport reads the report of the previous successful run. It
+// returns nil if there is no such report.
+func (o *Output) previousReport() (*Report, error) {
+	path := filepath.Join(o.cfg.WorkDir(), reportFile+".json")
+	js, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var res Report
+	err = json.Unmarshal(js, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// compare calculates changes of entries numbers against the previous
+// report and finds files that shrank more than their limits.
+func (r *Report) compare(prev *Report, limits map[string]float64) {
+	r.PreviousDate = prev.Date
+	r.Changes = make(map[string]float64)
+	for _, v := range r.Files {
+		old, ok := prev.Entries[v]
+		if !ok || old == 0 {
+			continue
+		}
+		change := float64(r.Entries[v]-old) / float64(old) * 100
+		r.Changes[v] = math.Round(change*100) / 100
+		if limit, ok := limits[v]; ok && -change > limit {
+			r.Shrunk = append(r.Shrunk, v)
+		}
+	}
+}
+
+// Markdown returns the report as a Markdown document.
+func (r Report) Markdown() string {
+	var b strings.Builder
+	b.WriteString("# gndict report\n\n")
+	fmt.Fprintf(&b, "Date: %s\n\n", r.Date)
+	if r.Scope != "" {
+		fmt.Fprintf(&b, "Scope: %s\n\n", r.Scope)
+	}
+	if r.PreviousDate != "" {
+		fmt.Fprintf(&b, "Compared to: %s\n\n", r.PreviousDate)
+	}
+
+	b.WriteString("## Names\n\n")
+	b.WriteString("| | Count |\n|---|---:|\n")
+	fmt.Fprintf(&b, "| read | %d |\n", r.NamesRead)
+	keys := make([]string, 0, len(r.Skipped))
+	for k := range r.Skipped {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "| skipped: %s | %d |\n", k, r.Skipped[k])
+	}
+
+	b.WriteString("\n## Files\n\n")
+	b.WriteString("| File | Entries | Change |\n|---|---:|---:|\n")
+	for _, v := range r.Files {
+		change := ""
+		if c, ok := r.Changes[v]; ok {
+			change = fmt.Sprintf("%+.2f%%", c)
+		}
+		if slices.Contains(r.Shrunk, v) {
+			change = "**" + change + "**"
+		}
+		fmt.Fprintf(&b, "| %s | %d | %s |\n", v, r.Entries[v], change)
+	}
+
+	if len(r.Shrunk) > 0 {
+		b.WriteString("\n## Shrunk more than allowed\n\n")
+		for _, v := range r.Shrunk {
+			fmt.Fprintf(&b, "- %s\n", v)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,177 @@
+package ent
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gnames/gndict/internal/ent/data"
+)
+
+// Weights of the signals that form the ambiguity score. They add up to 1.
+const (
+	weightLen    = 0.35
+	weightCommon = 0.35
+	weightEdit   = 0.1
+	weightRare   = 0.1
+	weightSpread = 0.1
+)
+
+// scorer calculates ambiguity scores of words. The score is between 0 and 1,
+// words with higher scores are more likely to appear in texts without being
+// a part of a scientific name.
+type scorer struct {
+	dat *data.Data
+	// commonDel contains common words and all variants of them with one
+	// deleted character.
+	commonDel map[string]struct{}
+	// kingdoms are numbers of kingdoms that use genera.
+	kingdoms map[string]int
+}
+
+// score contains the ambiguity score together with the signal that
+// contributed to it the most.
+type score struct {
+	value   float64
+	reason  string
+	signals []signal
+}
+
+// signal is a weighted contribution to the ambiguity score.
+type signal struct {
+	reason string
+	value  float64
+}
+
+func newScorer(dat *data.Data) *scorer {
+	res := &scorer{dat: dat, commonDel: make(map[string]struct{})}
+	for k := range dat.Common {
+		res.commonDel[k] = struct{}{}
+		for _, v := range deletes(k) {
+			res.commonDel[v] = struct{}{}
+		}
+	}
+	return res
+}
+
+// score calculates the ambiguity score of a word from a CSV row of
+// preprocessed data that has 'word,count,sources' format. Words shorter
+// than minLen get the highest length signal.
+func (s *scorer) score(row string, minLen int) score {
+	fields := strings.Split(row, ",")
+	word := fields[0]
+	var count, sources int
+	if len(fields) > 1 {
+		count, _ = strconv.Atoi(fields[1])
+	}
+	if len(fields) > 2 {
+		sources, _ = strconv.Atoi(fields[2])
+	}
+	low := strings.ToLower(word)
+
+	signals := []signal{
+		{"short", weightLen * lenSignal(word, minLen)},
+		{"common", weightCommon * s.commonSignal(low)},
+		{"edit", weightEdit * s.editSignal(low)},
+		{"rare", weightRare * rareSignal(count)},
+		{"spread", weightSpread * spreadSignal(sources, s.kingdoms[word])},
+	}
+
+	res := score{signals: signals}
+	var top float64
+	for _, v := range signals {
+		res.value += v.value
+		if v.value > top {
+			top = v.value
+			res.reason = v.reason
+		}
+	}
+	res.value = math.Min(1, res.value)
+	return res
+}
+
+// lenSignal is 1 for words shorter than minLen and decreases to 0 for words
+// that are 2 letters longer than minLen.
+func lenSignal(word string, minLen int) float64 {
+	switch l := utf8.RuneCountInString(word); {
+	case l < minLen:
+		return 1
+	case l == minLen:
+		return 0.5
+	case l == minLen+1:
+		return 0.25
+	default:
+		return 0
+	}
+}
+
+// commonSignal is the fraction of common-word lists that contain the word.
+func (s *scorer) commonSignal(low string) float64 {
+	if len(s.dat.CommonLists) == 0 {
+		return 0
+	}
+	var n int
+	for _, v := range s.dat.CommonLists {
+		if _, ok := v[low]; ok {
+			n++
+		}
+	}
+	return float64(n) / float64(len(s.dat.CommonLists))
+}
+
+// editSignal is 1 if a word is not a common word, but is very close
+// to one. Only words longer than 4 letters are checked, because short words
+// are close to too many common words. The distance is estimated by
+// symmetric deletion, so it finds common words with one inserted, deleted
+// or replaced character.
+func (s *scorer) editSignal(low string) float64 {
+	if utf8.RuneCountInString(low) < 5 {
+		return 0
+	}
+	if _, ok := s.dat.Common[low]; ok {
+		return 0
+	}
+	if _, ok := s.commonDel[low]; ok {
+		return 1
+	}
+	for _, v := range deletes(low) {
+		if _, ok := s.commonDel[v]; ok {
+			return 1
+		}
+	}
+	return 0
+}
+
+// setKingdoms sets the numbers of kingdoms of genera.
+func (s *scorer) setKingdoms(kingdoms map[string]int) {
+	s.kingdoms = kingdoms
+}
+
+// rareSignal decreases when a word is found in many names. It is 1 for
+// words that are not found and 0 for words of 1000 names or more.
+func rareSignal(count int) float64 {
+	return 1 - math.Min(1, math.Log10(float64(count)+1)/3)
+}
+
+// spreadSignal grows when a name is used by many data sources or by several
+// kingdoms. Such names are used for different taxa, so they are ambiguous.
+// It is 1 for names of 10 data sources or of 3 kingdoms.
+func spreadSignal(sources, kingdoms int) float64 {
+	src := math.Min(1, float64(sources)/10)
+	var kng float64
+	if kingdoms > 1 {
+		kng = math.Min(1, float64(kingdoms-1)/2)
+	}
+	return math.Max(src, kng)
+}
+
+// deletes returns all variants of a word with one character removed.
+func deletes(word string) []string {
+	rs := []rune(word)
+	res := make([]string, len(rs))
+	for i := range rs {
+		res[i] = string(rs[:i]) + string(rs[i+1:])
+	}
+	return res
+}
@@ -0,0 +1,120 @@
+package ent
+
+import (
+	"testing"
+
+	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func scoreData() *data.Data {
+	return &data.Data{
+		Common: map[string]struct{}{"major": {}, "house": {}},
+		CommonLists: map[string]map[string]struct{}{
+			"eu": {"major": {}, "house": {}},
+			"de": {"major": {}},
+		},
+	}
+}
+
+func TestLenSignal(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		msg, word string
+		res       float64
+	}{
+		{"short", "Poa", 1},
+		{"min length", "Rosa", 0.5},
+		{"one longer", "Pinus", 0.25},
+		{"long", "Quercus", 0},
+		{"runes", "Aëdé", 0.5},
+	}
+	for _, v := range tests {
+		assert.Equal(v.res, lenSignal(v.word, 4), v.msg)
+	}
+}
+
+func TestRareSignal(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(1.0, rareSignal(0))
+	assert.InDelta(0, rareSignal(999), 0.001)
+	assert.Equal(0.0, rareSignal(100000))
+	// rare words are more ambiguous.
+	assert.Greater(rareSignal(3), rareSignal(300))
+}
+
+func TestSpreadSignal(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		msg               string
+		sources, kingdoms int
+		res               float64
+	}{
+		{"nothing", 0, 0, 0},
+		{"one source", 1, 1, 0.1},
+		{"many sources", 10, 1, 1},
+		{"more than max sources", 50, 0, 1},
+		{"two kingdoms", 1, 2, 0.5},
+		{"three kingdoms", 1, 3, 1},
+		{"sources win", 8, 2, 0.8},
+	}
+	for _, v := range tests {
+		assert.InDelta(v.res, spreadSignal(v.sources, v.kingdoms), 1e-9, v.msg)
+	}
+}
+
+func TestCommonSignal(t *testing.T) {
+	assert := assert.New(t)
+	sc := newScorer(scoreData())
+	assert.Equal(1.0, sc.commonSignal("major"))
+	assert.Equal(0.5, sc.commonSignal("house"))
+	assert.Equal(0.0, sc.commonSignal("quercus"))
+	assert.Equal(0.0, newScorer(&data.Data{}).commonSignal("major"))
+}
+
+func TestEditSignal(t *testing.T) {
+	assert := assert.New(t)
+	sc := newScorer(scoreData())
+	tests := []struct {
+		msg, word string
+		res       float64
+	}{
+		{"common word", "house", 0},
+		{"replaced", "horse", 1},
+		{"inserted", "houses", 1},
+		{"deleted", "hose", 0},
+		{"deleted long", "majr", 0},
+		{"far", "quercus", 0},
+	}
+	for _, v := range tests {
+		assert.Equal(v.res, sc.editSignal(v.word), v.msg)
+	}
+}
+
+func TestScore(t *testing.T) {
+	assert := assert.New(t)
+	sc := newScorer(scoreData())
+	sc.setKingdoms(map[string]int{"Morus": 2, "Quercus": 1})
+
+	// names used by more data sources are more ambiguous.
+	few := sc.score("Betula,100,1", 4)
+	many := sc.score("Betula,100,10", 4)
+	assert.Greater(many.value, few.value)
+
+	// genera of several kingdoms are more ambiguous.
+	one := sc.score("Quercus,100,1", 4)
+	two := sc.score("Morus,100,1", 4)
+	assert.Greater(two.value, one.value)
+	assert.InDelta(weightSpread*0.5, two.signals[4].value, 1e-9)
+	assert.Equal("spread", two.signals[4].reason)
+
+	// rare names are more ambiguous.
+	rare := sc.score("Betula,1,1", 4)
+	assert.Greater(rare.value, few.value)
+
+	// the score and the main reason of a common short word.
+	res := sc.score("Major,1000,1", 4)
+	assert.Equal("common", res.reason)
+	assert.LessOrEqual(res.value, 1.0)
+	assert.InDelta(weightLen*0.25+weightCommon+weightSpread*0.1, res.value, 1e-9)
+}
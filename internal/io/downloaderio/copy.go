@@ -0,0 +1,180 @@
+package downloaderio
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/gnames/gndict/internal/compress"
+	"github.com/gnames/gndict/internal/progress"
+)
+
+// copyTo saves results of a query to a file using COPY. Fields of rows are
+// joined by commas without quoting, so readers can split a row on its last
+// comma. The file is compressed according to the Compression setting.
+// It returns the number of saved rows. If copying fails, rows of the failed
+// attempt are taken back from the bar, so a retried query does not count
+// them twice.
+func (d *downloaderio) copyTo(
+	ctx context.Context,
+	path, query string,
+	bar *progress.Bar,
+) (int64, error) {
+	w, err := compress.Create(path, d.cfg.Compression)
+	if err != nil {
+		return 0, err
+	}
+	defer w.Close()
+
+	conn, err := d.db.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	// CSV format quotes fields with commas and quotes, rows are decoded
+	// back to raw fields while they are streamed to the file.
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	var rows int
+	go func() {
+		var err error
+		rows, err = rawRows(pr, w, bar)
+		pr.CloseWithError(err)
+		done <- err
+	}()
+
+	q := "COPY (" + query + ") TO STDOUT WITH (FORMAT csv)"
+	tag, err := conn.Conn().PgConn().CopyTo(ctx, pw, q)
+	pw.CloseWithError(err)
+	if errRows := <-done; err == nil {
+		err = errRows
+	}
+	if err != nil {
+		bar.Add(-rows)
+		return 0, err
+	}
+	return tag.RowsAffected(), w.Close()
+}
+
+// rawRows reads CSV rows and writes their fields joined by commas. It
+// returns the number of written rows.
+func rawRows(r io.Reader, w io.Writer, bar *progress.Bar) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	var res int
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return res, err
+		}
+		_, err = io.WriteString(w, strings.Join(row, ",")+"\n")
+		if err != nil {
+			return res, err
+		}
+		res++
+		bar.Increment()
+	}
+}
+
+// quoteLiteral makes an SQL string literal. COPY does not take query
+// parameters, so values have to be a part of the query.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// nameRow is a 'name,sources' row of a names dump.
+type nameRow struct {
+	line, name string
+}
+
+// nameStream reads sorted rows of a names dump.
+type nameStream struct {
+	sc  *bufio.Scanner
+	row *nameRow
+}
+
+func (s *nameStream) next() {
+	s.row = nil
+	if !s.sc.Scan() {
+		return
+	}
+	line := s.sc.Text()
+	name := line
+	if i := strings.LastIndexByte(line, ','); i > -1 {
+		name = line[:i]
+	}
+	s.row = &nameRow{line: line, name: name}
+}
+
+// mergeNames merges sorted name dumps into one sorted file. Rows of the
+// extra names are added only if the name is not in the dumps.
+func (d *downloaderio) mergeNames(
+	path string,
+	parts []string,
+	extra []string,
+) error {
+	w, err := compress.Create(path, d.cfg.Compression)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	var streams []*nameStream
+	for _, v := range parts {
+		r, err := compress.Open(v)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		sc := bufio.NewScanner(r)
+		streams = append(streams, &nameStream{sc: sc})
+	}
+	ex := bufio.NewScanner(strings.NewReader(strings.Join(extra, "\n")))
+	exStream := &nameStream{sc: ex}
+	streams = append(streams, exStream)
+	for _, v := range streams {
+		v.next()
+	}
+
+	for {
+		// there are few streams, so linear search of the smallest row
+		// is fast enough.
+		var first *nameStream
+		for _, v := range streams {
+			if v.row == nil {
+				continue
+			}
+			if first == nil || v.row.name < first.row.name ||
+				(v.row.name == first.row.name && first == exStream) {
+				first = v
+			}
+		}
+		if first == nil {
+			break
+		}
+		_, err = io.WriteString(w, first.row.line+"\n")
+		if err != nil {
+			return err
+		}
+		name := first.row.name
+		for _, v := range streams {
+			for v.row != nil && v.row.name == name {
+				v.next()
+			}
+		}
+	}
+
+	for _, v := range streams {
+		if err = v.sc.Err(); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
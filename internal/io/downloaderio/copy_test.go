@@ -0,0 +1,73 @@
+package downloaderio
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gnames/gndict/internal/compress"
+	"github.com/gnames/gndict/internal/progress"
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawRows(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	in := "Aus bus,3\n" +
+		"\"Aus bus, 1758\",2\n" +
+		"\"Aus \"\"bus\"\"\",1\n" +
+		"Morus,Plantae\n"
+	var out strings.Builder
+	bar := progress.New(context.Background(), "test", 0)
+	rows, err := rawRows(strings.NewReader(in), &out, bar)
+	bar.Finish()
+	require.Nil(err)
+	assert.Equal(4, rows)
+	assert.Equal(
+		"Aus bus,3\nAus bus, 1758,2\nAus \"bus\",1\nMorus,Plantae\n",
+		out.String(),
+	)
+}
+
+func TestMergeNames(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	dir := t.TempDir()
+	cfg := config.New(
+		config.OptCacheDir(dir),
+		config.OptCompression(compress.Gzip),
+	)
+	d := &downloaderio{cfg: cfg}
+
+	var paths []string
+	for i, v := range [][]string{
+		{"Aus bus,3", "Poa annua,5"},
+		{"Abies alba,2", "Rosa canina,1"},
+	} {
+		path := filepath.Join(dir, "part"+string(rune('0'+i)))
+		w, err := compress.Create(path, compress.Zstd)
+		require.Nil(err)
+		_, err = w.Write([]byte(strings.Join(v, "\n") + "\n"))
+		require.Nil(err)
+		require.Nil(w.Close())
+		paths = append(paths, path)
+	}
+	// names from the database win over extra names.
+	extra := []string{"Aus bus,1", "Bacteria,1", "Zea,1"}
+
+	path := filepath.Join(dir, "names.txt")
+	require.Nil(d.mergeNames(path, paths, extra))
+	r, err := compress.Open(path)
+	require.Nil(err)
+	defer r.Close()
+	res, err := io.ReadAll(r)
+	require.Nil(err)
+	assert.Equal(
+		"Abies alba,2\nAus bus,3\nBacteria,1\nPoa annua,5\nRosa canina,1\nZea,1\n",
+		string(res),
+	)
+}
@@ -3,78 +3,195 @@ package downloaderio
 import (
 	"context"
 	"fmt"
-	"os"
 	"path/filepath"
-	"sort"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/gnames/gndict/internal/compress"
 	"github.com/gnames/gndict/internal/ent"
 	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/gnames/gndict/internal/progress"
 	"github.com/gnames/gndict/pkg/config"
 	"github.com/gnames/gnsys"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
 )
 
 var (
-	names  = "names.txt"
-	genera = "genera.txt"
+	namesFile    = "names.txt"
+	generaFile   = "genera.txt"
+	kingdomsFile = "kingdoms.txt"
 )
 
 type downloaderio struct {
 	cfg config.Config
-	db  *pgx.Conn
+	db  *pgxpool.Pool
 }
 
-func New(cfg config.Config) ent.Downloader {
-	db, err := pgx.Connect(context.Background(), getURL(cfg))
+// New connects to the gnames database and creates the WorkDir if it does
+// not exist. If the database cannot be reached, the error wraps
+// ent.ErrNoDatabase.
+func New(ctx context.Context, cfg config.Config) (ent.Downloader, error) {
+	pcfg, err := pgxpool.ParseConfig(getURL(cfg))
 	if err != nil {
-		log.Fatal().Err(err).Msg("Cannot create PostgreSQL connection")
+		return nil, fmt.Errorf("%w: %w", ent.ErrNoDatabase, err)
 	}
-	exist, _, _ := gnsys.DirExists(cfg.CacheDir)
+	// names jobs run together with genera and kingdoms downloads.
+	pcfg.MaxConns = int32(cfg.DownloadJobs + 2)
+	db, err := pgxpool.NewWithConfig(ctx, pcfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ent.ErrNoDatabase, err)
+	}
+	err = db.Ping(ctx)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w: %w", ent.ErrNoDatabase, err)
+	}
+	workDir := cfg.WorkDir()
+	exist, _, _ := gnsys.DirExists(workDir)
 	if !exist {
-		log.Info().Msgf("Dir %s does not exist, creating.", cfg.CacheDir)
-		err := gnsys.MakeDir(cfg.CacheDir)
+		log.Info().Msgf("Dir %s does not exist, creating.", workDir)
+		err := gnsys.MakeDir(workDir)
 		if err != nil {
-			log.Fatal().Err(err).Msgf("Cannot make dir %s", cfg.CacheDir)
+			db.Close()
+			err = fmt.Errorf("-> gnsys.MakeDir %s: %w", workDir, err)
+			return nil, err
 		}
 	}
-	return &downloaderio{cfg: cfg, db: db}
+	return &downloaderio{cfg: cfg, db: db}, nil
 }
 
 func (d *downloaderio) Close() error {
-	return d.db.Close(context.Background())
+	d.db.Close()
+	return nil
 }
 
-func (d *downloaderio) Download(dat *data.Data) error {
+func (d *downloaderio) Download(ctx context.Context, dat *data.Data) error {
 	if !d.cfg.ForceDownload && d.downloadHappened() {
 		log.Info().Msg("Download is already done, skipping...")
 		return nil
 	}
 
-	log.Info().Msg("Starting creation of the names dump.")
-	err := d.getNames(dat)
+	err := d.download(ctx, dat)
 	if err != nil {
+		d.cleanup()
 		return err
 	}
+	return nil
+}
 
-	log.Info().Msg("Starting creation of genera dump.")
-	err = d.getGenera()
-	if err != nil {
-		return err
+// download runs names, genera and kingdoms dumps in parallel.
+func (d *downloaderio) download(ctx context.Context, dat *data.Data) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		log.Info().Msg("Starting creation of the names dump.")
+		return d.getNames(ctx, dat)
+	})
+	g.Go(func() error {
+		log.Info().Msg("Starting creation of genera dump.")
+		return d.retry(ctx, "genera", func() error {
+			return d.getGenera(ctx)
+		})
+	})
+	g.Go(func() error {
+		log.Info().Msg("Starting creation of genera kingdoms dump.")
+		return d.retry(ctx, "kingdoms", func() error {
+			return d.getKingdoms(ctx)
+		})
+	})
+	return g.Wait()
+}
+
+// cleanup removes downloaded files, so an interrupted download is not
+// mistaken for a finished one.
+func (d *downloaderio) cleanup() {
+	for _, v := range []string{namesFile, generaFile, kingdomsFile} {
+		path := filepath.Join(d.cfg.WorkDir(), v)
+		err := compress.Remove(path)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Cannot remove %s", path)
+		}
 	}
-	return nil
 }
 
-func (d *downloaderio) getNames(dat *data.Data) error {
-	names := make(map[string]struct{})
-	path := filepath.Join(d.cfg.CacheDir, "names.txt")
-	f, err := os.Create(path)
+// getNames saves canonical forms of names together with the number of data
+// sources that use them. Lines of the file have 'name,sources' format and
+// are sorted by name. If DownloadJobs is larger than 1, names are
+// downloaded by parallel range scans of canonical IDs into separate files
+// that are merged afterwards.
+func (d *downloaderio) getNames(ctx context.Context, dat *data.Data) error {
+	bar := progress.New(ctx, "download names", d.estimate(ctx, "canonicals"))
+	ranges := idRanges(d.cfg.DownloadJobs)
+	parts := make([]string, len(ranges))
+	counts := make([]int64, len(ranges))
+	g, gctx := errgroup.WithContext(ctx)
+	for i, r := range ranges {
+		parts[i] = filepath.Join(
+			d.cfg.WorkDir(), fmt.Sprintf("%s.part%d", namesFile, i),
+		)
+		g.Go(func() error {
+			job := fmt.Sprintf("names %d/%d", i+1, len(ranges))
+			return d.retry(gctx, job, func() error {
+				var err error
+				counts[i], err = d.copyTo(gctx, parts[i], d.namesQuery(r), bar)
+				return err
+			})
+		})
+	}
+	defer func() {
+		for _, v := range parts {
+			compress.Remove(v)
+		}
+	}()
+	err := g.Wait()
+	bar.Finish()
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	q := `
-	SELECT DISTINCT c.name
+
+	var total int64
+	for _, v := range counts {
+		total += v
+	}
+	if total == 0 {
+		return fmt.Errorf("%w: no names found", ent.ErrEmptyDownload)
+	}
+
+	// ION names cannot be filtered by a scope, so they are used only for
+	// the full dictionary.
+	var ion []string
+	if d.cfg.Scope == "" {
+		ion = make([]string, 0, len(dat.ION))
+		for k := range dat.ION {
+			ion = append(ion, k)
+		}
+		// names are sorted before the sources are added, the same way
+		// they are sorted by the database.
+		slices.Sort(ion)
+		for i := range ion {
+			ion[i] += ",1"
+		}
+	}
+
+	path := filepath.Join(d.cfg.WorkDir(), namesFile)
+	return d.mergeNames(path, parts, ion)
+}
+
+// namesQuery returns a query for names with canonical IDs from the range.
+// Every name has one canonical ID, so ranges do not share names. Names are
+// sorted in byte order to be merged.
+func (d *downloaderio) namesQuery(r idRange) string {
+	cond := d.scopeCond("nsi")
+	if r.from != "" {
+		cond += " AND ns.canonical_id >= " + quoteLiteral(r.from) + "::uuid"
+	}
+	if r.to != "" {
+		cond += " AND ns.canonical_id < " + quoteLiteral(r.to) + "::uuid"
+	}
+	return `
+	SELECT c.name, count(DISTINCT nsi.data_source_id)
 	    FROM canonicals c
 	        JOIN name_strings ns
 	            ON ns.canonical_id = c.id
@@ -82,90 +199,137 @@ func (d *downloaderio) getNames(dat *data.Data) error {
 	            ON nsi.name_string_id = ns.id
 	        JOIN data_sources ds
 	            ON ds.id = nsi.data_source_id
-	    WHERE ds.is_curated = true
-			OR nsi.data_source_id = 11
-			OR nsi.data_source_id = 12
-			OR nsi.data_source_id = 206
+	    WHERE ` + d.sourcesCond() + `
+			` + cond + `
+	    GROUP BY c.name
+	    ORDER BY c.name COLLATE "C"
 `
-	rows, err := d.db.Query(context.Background(), q)
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
-	var name string
-	for rows.Next() {
-		err = rows.Scan(&name)
-		if err != nil {
-			return err
-		}
-		names[name] = struct{}{}
-	}
+}
 
-	for k := range dat.ION {
-		names[k] = struct{}{}
+// sourcesCond returns SQL condition that limits names to DataSources, or
+// to curated and a few large data sources if DataSources are not set.
+func (d *downloaderio) sourcesCond() string {
+	if len(d.cfg.DataSources) == 0 {
+		return "(ds.is_curated = true OR nsi.data_source_id IN (11, 12, 206))"
 	}
-
-	namesAry := make([]string, len(names))
-	var i int
-	for k := range names {
-		namesAry[i] = k
-		i++
+	ids := make([]string, len(d.cfg.DataSources))
+	for i, v := range d.cfg.DataSources {
+		ids[i] = strconv.Itoa(v)
 	}
+	return "nsi.data_source_id IN (" + strings.Join(ids, ", ") + ")"
+}
 
-	sort.Strings(namesAry)
+// idRange is a range of canonical IDs. Empty boundary means the range is
+// open from that side.
+type idRange struct {
+	from, to string
+}
 
-	for _, name := range namesAry {
-		_, err = f.WriteString(name + "\n")
-		if err != nil {
-			return err
+// idRanges splits UUIDs into n ranges of equal size. Canonical IDs are
+// UUID v5, so they are distributed evenly among the ranges.
+func idRanges(n int) []idRange {
+	if n <= 1 {
+		return []idRange{{}}
+	}
+	step := (uint64(1) << 32) / uint64(n)
+	bound := func(i int) string {
+		return fmt.Sprintf("%08x-0000-0000-0000-000000000000", uint64(i)*step)
+	}
+	res := make([]idRange, n)
+	for i := range res {
+		if i > 0 {
+			res[i].from = bound(i)
+		}
+		if i < n-1 {
+			res[i].to = bound(i + 1)
 		}
 	}
-	return nil
+	return res
 }
 
-func (d *downloaderio) getGenera() error {
-	path := filepath.Join(d.cfg.CacheDir, "genera.txt")
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+func (d *downloaderio) getGenera(ctx context.Context) error {
+	path := filepath.Join(d.cfg.WorkDir(), generaFile)
 	// IRMNG data source ID is 181
 	q := `
 SELECT DISTINCT c.name
     FROM name_string_indices nsi
         JOIN name_strings ns on ns.id = nsi.name_string_id
         JOIN canonicals c on c.id = ns.canonical_id
-    WHERE data_source_id = 181 AND RANK = 'Genus' `
-	rows, err := d.db.Query(context.Background(), q)
+    WHERE data_source_id = 181 AND RANK = 'Genus' ` + d.scopeCond("nsi")
+	bar := progress.New(ctx, "download genera", 0)
+	defer bar.Finish()
+	n, err := d.copyTo(ctx, path, q, bar)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
-	var name string
-	for rows.Next() {
-		err = rows.Scan(&name)
-		if err != nil {
-			return err
-		}
-		_, err = f.WriteString(name + "\n")
-		if err != nil {
-			return err
-		}
+	if n == 0 {
+		return fmt.Errorf("%w: no genera found", ent.ErrEmptyDownload)
 	}
-
 	return nil
 }
 
+// getKingdoms saves kingdoms of genera from reliable data sources and
+// IRMNG. Lines of the file have 'genus,kingdom' format.
+func (d *downloaderio) getKingdoms(ctx context.Context) error {
+	path := filepath.Join(d.cfg.WorkDir(), kingdomsFile)
+	q := `
+SELECT DISTINCT name, kingdom FROM (
+	SELECT c.name,
+		(string_to_array(nsi.classification, '|'))[
+			array_position(
+				string_to_array(lower(nsi.classification_ranks), '|'), 'kingdom'
+			)
+		] AS kingdom
+		FROM name_string_indices nsi
+			JOIN name_strings ns ON ns.id = nsi.name_string_id
+			JOIN canonicals c ON c.id = ns.canonical_id
+			JOIN data_sources ds ON ds.id = nsi.data_source_id
+		WHERE lower(nsi.rank) = 'genus'
+			AND (ds.is_curated = true
+				OR nsi.data_source_id IN (11, 12, 181, 206))
+			` + d.scopeCond("nsi") + `
+	) k
+	WHERE kingdom IS NOT NULL AND kingdom != ''`
+	bar := progress.New(ctx, "download kingdoms", 0)
+	defer bar.Finish()
+	_, err := d.copyTo(ctx, path, q, bar)
+	return err
+}
+
+// estimate returns the approximate number of rows in a table from
+// PostgreSQL statistics, or 0 if it is unknown.
+func (d *downloaderio) estimate(ctx context.Context, table string) int {
+	var res float64
+	q := "SELECT reltuples FROM pg_class WHERE relname = $1"
+	err := d.db.QueryRow(ctx, q, table).Scan(&res)
+	if err != nil || res < 0 {
+		return 0
+	}
+	return int(res)
+}
+
+// scopeCond returns SQL condition that limits name_string_indices records
+// to the taxonomic scope, or an empty string if scope is not set.
+func (d *downloaderio) scopeCond(alias string) string {
+	if d.cfg.Scope == "" {
+		return ""
+	}
+	return fmt.Sprintf(
+		"AND lower(%s) = ANY(string_to_array(lower(%s.classification), '|'))",
+		quoteLiteral(d.cfg.Scope), alias,
+	)
+}
+
 func getURL(cfg config.Config) string {
 	return fmt.Sprintf("postgres://%s:%s@%s:5432/%s?sslmode=disable",
 		cfg.PgUser, cfg.PgPass, cfg.PgHost, cfg.PgDb)
 }
 
 func (d *downloaderio) downloadHappened() bool {
-	names := filepath.Join(d.cfg.CacheDir, "names.txt")
-	genera := filepath.Join(d.cfg.CacheDir, "genera.txt")
-	namesExist, _ := gnsys.FileExists(names)
-	generaExist, _ := gnsys.FileExists(genera)
-	return namesExist && generaExist
+	for _, v := range []string{namesFile, generaFile, kingdomsFile} {
+		if _, ok := compress.Find(filepath.Join(d.cfg.WorkDir(), v)); !ok {
+			return false
+		}
+	}
+	return true
 }
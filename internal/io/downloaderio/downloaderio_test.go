@@ -0,0 +1,46 @@
+package downloaderio
+
+import (
+	"testing"
+
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDRanges(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]idRange{{}}, idRanges(0))
+	assert.Equal([]idRange{{}}, idRanges(1))
+
+	res := idRanges(4)
+	assert.Equal([]idRange{
+		{to: "40000000-0000-0000-0000-000000000000"},
+		{
+			from: "40000000-0000-0000-0000-000000000000",
+			to:   "80000000-0000-0000-0000-000000000000",
+		},
+		{
+			from: "80000000-0000-0000-0000-000000000000",
+			to:   "c0000000-0000-0000-0000-000000000000",
+		},
+		{from: "c0000000-0000-0000-0000-000000000000"},
+	}, res)
+
+	// ranges follow each other without gaps.
+	res = idRanges(7)
+	assert.Len(res, 7)
+	for i := 1; i < len(res); i++ {
+		assert.Equal(res[i-1].to, res[i].from)
+	}
+}
+
+func TestSourcesCond(t *testing.T) {
+	assert := assert.New(t)
+	d := &downloaderio{cfg: config.New()}
+	assert.Contains(d.sourcesCond(), "ds.is_curated = true")
+	assert.Contains(d.namesQuery(idRange{}), d.sourcesCond())
+
+	d.cfg = config.New(config.OptDataSources([]int{1, 169}))
+	assert.Equal("nsi.data_source_id IN (1, 169)", d.sourcesCond())
+	assert.NotContains(d.namesQuery(idRange{}), "is_curated")
+}
@@ -0,0 +1,86 @@
+package downloaderio
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+)
+
+// retryDelay is the delay before the first retry. Every next retry waits
+// twice as long.
+var retryDelay = 2 * time.Second
+
+// retry runs fn and repeats it after transient errors with exponential
+// backoff. Other errors are returned right away.
+func (d *downloaderio) retry(
+	ctx context.Context,
+	job string,
+	fn func() error,
+) error {
+	delay := retryDelay
+	for i := 0; ; i++ {
+		err := fn()
+		if err == nil || i >= d.cfg.DownloadRetries || !isTransient(err) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+
+		// jitter keeps parallel jobs from retrying at the same time.
+		wait := delay + rand.N(delay/2)
+		log.Warn().Err(err).
+			Str("job", job).
+			Int("attempt", i+1).
+			Str("wait", wait.Round(time.Millisecond).String()).
+			Msg("Transient database error, retrying")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// isTransient checks if an error might go away if the query is repeated.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		// connection exceptions
+		case len(pgErr.Code) == 5 && pgErr.Code[:2] == "08":
+			return true
+		// serialization failure, deadlock, too many connections,
+		// admin shutdown, crash shutdown, cannot connect now
+		case pgErr.Code == "40001", pgErr.Code == "40P01",
+			pgErr.Code == "53300", pgErr.Code == "57P01",
+			pgErr.Code == "57P02", pgErr.Code == "57P03":
+			return true
+		}
+		return false
+	}
+
+	if pgconn.SafeToRetry(err) || pgconn.Timeout(err) {
+		return true
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
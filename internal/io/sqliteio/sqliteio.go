@@ -0,0 +1,105 @@
+package sqliteio
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gnames/gndict/internal/ent"
+	"github.com/gnames/gndict/pkg/config"
+	_ "modernc.org/sqlite"
+)
+
+// DBFile is the name of SQLite database file in the dict directory.
+const DBFile = "gndict.sqlite"
+
+var tables = []string{"uninomials", "genera", "species", "canonicals", "common"}
+
+type sqliteio struct {
+	cfg config.Config
+	db  *sql.DB
+}
+
+// New creates an ent.Store that saves dictionary to a SQLite database.
+func New(cfg config.Config) ent.Store {
+	return &sqliteio{cfg: cfg}
+}
+
+func (s *sqliteio) Init() error {
+	path := filepath.Join(s.cfg.BuildDir(), DBFile)
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	s.db, err = sql.Open("sqlite", path)
+	if err != nil {
+		err = fmt.Errorf("-> sql.Open: %w", err)
+		return err
+	}
+	for _, v := range tables {
+		q := fmt.Sprintf(`
+CREATE TABLE %[1]s (
+	name TEXT NOT NULL,
+	count INTEGER NOT NULL DEFAULT 0,
+	bucket TEXT NOT NULL,
+	grey_reason TEXT NOT NULL DEFAULT '',
+	black_hit TEXT NOT NULL DEFAULT '',
+	score REAL NOT NULL DEFAULT 0,
+	derived INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX %[1]s_name_idx ON %[1]s (name);
+CREATE INDEX %[1]s_bucket_idx ON %[1]s (bucket, count);
+CREATE INDEX %[1]s_grey_reason_idx ON %[1]s (grey_reason);
+CREATE INDEX %[1]s_black_hit_idx ON %[1]s (black_hit);
+`, v)
+		_, err = s.db.Exec(q)
+		if err != nil {
+			err = fmt.Errorf("-> db.Exec: %w", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sqliteio) Save(table string, recs []ent.Record) error {
+	if s.db == nil {
+		return fmt.Errorf("database for %s is not initialized", table)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		err = fmt.Errorf("-> db.Begin: %w", err)
+		return err
+	}
+	q := fmt.Sprintf(`
+INSERT INTO %s
+	(name, count, bucket, grey_reason, black_hit, score, derived)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`, table)
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		tx.Rollback()
+		err = fmt.Errorf("-> tx.Prepare: %w", err)
+		return err
+	}
+	defer stmt.Close()
+
+	for _, v := range recs {
+		_, err = stmt.Exec(
+			v.Name, v.Count, string(v.Bucket), v.GreyReason, v.BlackHit,
+			v.Score, v.Derived,
+		)
+		if err != nil {
+			tx.Rollback()
+			err = fmt.Errorf("-> stmt.Exec: %w", err)
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteio) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
@@ -0,0 +1,60 @@
+package sqliteio_test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gnames/gndict/internal/ent"
+	"github.com/gnames/gndict/internal/io/sqliteio"
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	cfg := config.New(config.OptCacheDir(t.TempDir()))
+	require.Nil(os.MkdirAll(cfg.BuildDir(), 0755))
+
+	st := sqliteio.New(cfg)
+	assert.NotNil(st.Save("species", nil))
+	require.Nil(st.Init())
+	recs := []ent.Record{
+		{Name: "alba", Count: 10, Bucket: ent.In, Score: 0.1},
+		{Name: "major", Count: 5, Bucket: ent.InAmbig, GreyReason: "common"},
+		{Name: "alb", Bucket: ent.NotIn, BlackHit: "blacklist"},
+		{Name: "albus", Count: 10, Bucket: ent.In, Derived: true},
+	}
+	require.Nil(st.Save("species", recs))
+	require.Nil(st.Close())
+
+	db, err := sql.Open("sqlite", filepath.Join(cfg.BuildDir(), sqliteio.DBFile))
+	require.Nil(err)
+	defer db.Close()
+	rows, err := db.Query(`
+SELECT name, count, bucket, grey_reason, black_hit, score, derived
+	FROM species ORDER BY name`)
+	require.Nil(err)
+	defer rows.Close()
+
+	var res []ent.Record
+	for rows.Next() {
+		var rec ent.Record
+		var bucket string
+		err = rows.Scan(&rec.Name, &rec.Count, &bucket, &rec.GreyReason,
+			&rec.BlackHit, &rec.Score, &rec.Derived)
+		require.Nil(err)
+		rec.Bucket = ent.Bucket(bucket)
+		res = append(res, rec)
+	}
+	require.Nil(rows.Err())
+	assert.Equal([]ent.Record{recs[2], recs[0], recs[3], recs[1]}, res)
+
+	var n int
+	err = db.QueryRow("SELECT count(*) FROM genera").Scan(&n)
+	assert.Nil(err)
+	assert.Equal(0, n)
+}
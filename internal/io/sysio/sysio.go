@@ -2,9 +2,13 @@ package sysio
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/gnames/gndict/internal/compress"
 	"github.com/gnames/gndict/internal/ent"
 	"github.com/gnames/gndict/pkg/config"
 )
@@ -17,28 +21,43 @@ func New(cfg config.Config) ent.Sys {
 	return sysio{cfg: cfg}
 }
 
-func (s sysio) Names() ([]string, error) {
-	return s.ReadFile("names.txt")
+// checkEvery is the number of lines read between checks of the context.
+const checkEvery = 10_000
+
+func (s sysio) Names(ctx context.Context) ([]string, error) {
+	return s.ReadFile(ctx, "names.txt")
+}
+
+func (s sysio) Canonicals(ctx context.Context) ([]string, error) {
+	return s.ReadFile(ctx, "canonicals.csv")
 }
 
-func (s sysio) Canonicals() ([]string, error) {
-	return s.ReadFile("canonicals.csv")
+func (s sysio) Genera(ctx context.Context) ([]string, error) {
+	return s.ReadFile(ctx, "genera.txt")
 }
 
-func (s sysio) Genera() ([]string, error) {
-	return s.ReadFile("genera.txt")
+func (s sysio) Kingdoms(ctx context.Context) ([]string, error) {
+	return s.ReadFile(ctx, "kingdoms.txt")
 }
 
-func (s sysio) ReadFile(fname string) ([]string, error) {
+func (s sysio) ReadFile(ctx context.Context, fname string) ([]string, error) {
 	var res []string
-	path := filepath.Join(s.cfg.CacheDir, fname)
-	f, err := os.Open(path)
+	path := filepath.Join(s.cfg.WorkDir(), fname)
+	f, err := compress.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ent.ErrCacheMissing, path)
+	}
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
+		if len(res)%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
 		txt := scanner.Text()
 		res = append(res, txt)
 	}
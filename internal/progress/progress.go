@@ -0,0 +1,187 @@
+// Package progress reports progress of long pipeline stages. On a terminal
+// it shows a progress bar, otherwise it periodically logs structured
+// messages with the number of processed rows, the rate and ETA.
+package progress
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cheggaaa/pb/v3"
+	"github.com/mattn/go-isatty"
+	"github.com/rs/zerolog/log"
+)
+
+// LogInterval is the time between log messages when the output is not
+// a terminal.
+var LogInterval = 10 * time.Second
+
+// barTmpl is the template of the progress bar with a known total.
+const barTmpl = `{{string . "stage"}} {{counters . }} {{bar . }} ` +
+	`{{percent . }} {{speed . "%s rows/s"}} {{rtime . "ETA %s"}}`
+
+// barTmplNoTotal is the template of the progress bar with unknown total.
+const barTmplNoTotal = `{{string . "stage"}} {{counters . }} ` +
+	`{{speed . "%s rows/s"}} {{etime . }}`
+
+// Timing is the duration of a finished stage.
+type Timing struct {
+	Stage    string
+	Rows     int64
+	Duration time.Duration
+}
+
+// barShown is true when a progress bar is on the terminal. Only one bar is
+// shown at a time, stages that run in parallel log their progress.
+var barShown atomic.Bool
+
+// Run collects durations of stages of one run of the pipeline.
+type Run struct {
+	mu      sync.Mutex
+	timings []Timing
+}
+
+// NewRun creates an empty Run.
+func NewRun() *Run {
+	return &Run{}
+}
+
+// Timings returns durations of all finished stages of the run.
+func (r *Run) Timings() []Timing {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := make([]Timing, len(r.timings))
+	copy(res, r.timings)
+	return res
+}
+
+func (r *Run) add(t Timing) {
+	r.mu.Lock()
+	r.timings = append(r.timings, t)
+	r.mu.Unlock()
+}
+
+// runKey is the context key of the Run.
+type runKey struct{}
+
+// WithRun returns a context that makes bars created with it keep their
+// durations in the run.
+func WithRun(ctx context.Context, r *Run) context.Context {
+	return context.WithValue(ctx, runKey{}, r)
+}
+
+// Bar reports the progress of a stage.
+type Bar struct {
+	stage   string
+	total   int64
+	count   atomic.Int64
+	start   time.Time
+	run     *Run
+	bar     *pb.ProgressBar
+	done    chan struct{}
+	stopped sync.WaitGroup
+	once    sync.Once
+}
+
+// New starts reporting progress of a stage. If total is unknown, it should
+// be 0. If ctx has a Run, the duration of the stage is kept in it.
+func New(ctx context.Context, stage string, total int) *Bar {
+	run, _ := ctx.Value(runKey{}).(*Run)
+	res := &Bar{
+		stage: stage,
+		total: int64(total),
+		start: time.Now(),
+		run:   run,
+		done:  make(chan struct{}),
+	}
+
+	if isatty.IsTerminal(os.Stderr.Fd()) && barShown.CompareAndSwap(false, true) {
+		tmpl := barTmpl
+		if total == 0 {
+			tmpl = barTmplNoTotal
+		}
+		res.bar = pb.New(total).
+			SetTemplateString(tmpl).
+			SetWriter(os.Stderr).
+			Set("stage", stage).
+			Start()
+		return res
+	}
+
+	res.stopped.Add(1)
+	go res.logLoop()
+	return res
+}
+
+// Add increases the number of processed rows.
+func (b *Bar) Add(n int) {
+	b.count.Add(int64(n))
+	if b.bar != nil {
+		b.bar.Add(n)
+	}
+}
+
+// Increment increases the number of processed rows by one.
+func (b *Bar) Increment() {
+	b.Add(1)
+}
+
+// Finish stops reporting, logs the duration of the stage and keeps it in
+// the Run. Calls after the first one do nothing.
+func (b *Bar) Finish() {
+	b.once.Do(b.finish)
+}
+
+func (b *Bar) finish() {
+	if b.bar != nil {
+		b.bar.Finish()
+		barShown.Store(false)
+	} else {
+		close(b.done)
+		b.stopped.Wait()
+	}
+
+	t := Timing{Stage: b.stage, Rows: b.count.Load(), Duration: time.Since(b.start)}
+	log.Info().
+		Str("stage", t.Stage).
+		Int64("rows", t.Rows).
+		Str("duration", t.Duration.Round(time.Millisecond).String()).
+		Msg("Stage is done")
+
+	if b.run != nil {
+		b.run.add(t)
+	}
+}
+
+func (b *Bar) logLoop() {
+	defer b.stopped.Done()
+	ticker := time.NewTicker(LogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			b.logProgress()
+		}
+	}
+}
+
+func (b *Bar) logProgress() {
+	count := b.count.Load()
+	elapsed := time.Since(b.start)
+	rate := float64(count) / elapsed.Seconds()
+	ev := log.Info().
+		Str("stage", b.stage).
+		Int64("rows", count).
+		Str("rate", fmt.Sprintf("%.0f rows/s", rate))
+	if b.total > 0 && rate > 0 && count < b.total {
+		eta := time.Duration(float64(b.total-count) / rate * float64(time.Second))
+		ev = ev.Int64("total", b.total).Str("eta", eta.Round(time.Second).String())
+	}
+	ev.Msg("Progress")
+}
@@ -0,0 +1,57 @@
+package progress_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gnames/gndict/internal/progress"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	assert := assert.New(t)
+	run := progress.NewRun()
+	ctx := progress.WithRun(context.Background(), run)
+
+	bar := progress.New(ctx, "stage one", 10)
+	bar.Add(3)
+	bar.Increment()
+	bar.Finish()
+	progress.New(ctx, "stage two", 0).Finish()
+
+	res := run.Timings()
+	assert.Len(res, 2)
+	assert.Equal("stage one", res[0].Stage)
+	assert.Equal(int64(4), res[0].Rows)
+	assert.Equal("stage two", res[1].Stage)
+
+	// runs do not share timings.
+	other := progress.NewRun()
+	progress.New(progress.WithRun(context.Background(), other), "x", 0).Finish()
+	assert.Len(other.Timings(), 1)
+	assert.Len(run.Timings(), 2)
+
+	// bars without a run are not kept anywhere.
+	progress.New(context.Background(), "no run", 0).Finish()
+	assert.Len(run.Timings(), 2)
+}
+
+func TestFinishTwice(t *testing.T) {
+	assert := assert.New(t)
+	run := progress.NewRun()
+	bar := progress.New(progress.WithRun(context.Background(), run), "x", 0)
+	bar.Increment()
+	bar.Finish()
+	assert.NotPanics(bar.Finish)
+	assert.Len(run.Timings(), 1)
+}
+
+func TestAddNegative(t *testing.T) {
+	assert := assert.New(t)
+	run := progress.NewRun()
+	bar := progress.New(progress.WithRun(context.Background(), run), "x", 0)
+	bar.Add(5)
+	bar.Add(-3)
+	bar.Finish()
+	assert.Equal(int64(2), run.Timings()[0].Rows)
+}
@@ -0,0 +1,183 @@
+// Package bloom provides a small Bloom filter that gndict writes beside
+// dictionary files. It allows clients like GNfinder to quickly reject words
+// that are certainly not in a dictionary, without loading the dictionary.
+package bloom
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+)
+
+// magic marks the beginning of a serialized filter.
+const magic = "GNBF"
+
+// version of the serialization format.
+const version uint8 = 1
+
+// Ext is the extension added to a dictionary file name to get the name of
+// its Bloom filter sidecar file.
+const Ext = ".bloom"
+
+// ErrFormat is returned when the data is not a serialized Bloom filter.
+var ErrFormat = errors.New("not a gndict bloom filter")
+
+// Filter is a Bloom filter. False positives are possible, false negatives
+// are not.
+type Filter struct {
+	// m is the number of bits.
+	m uint64
+	// k is the number of hash functions.
+	k uint32
+	// n is the number of added items.
+	n    uint64
+	bits []uint64
+}
+
+// New creates a filter that holds n items with a false-positive rate fp.
+func New(n int, fp float64) *Filter {
+	if n < 1 {
+		n = 1
+	}
+	if fp <= 0 || fp >= 1 {
+		fp = 0.01
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(fp) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint32(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &Filter{m: m, k: k, bits: make([]uint64, (m+63)/64)}
+}
+
+// Add inserts a word to the filter.
+func (f *Filter) Add(s string) {
+	h1, h2 := hashes(s)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		idx := (h1 + i*h2) % f.m
+		f.bits[idx/64] |= 1 << (idx % 64)
+	}
+	f.n++
+}
+
+// Test returns false if the word is definitely not in the filter, and true
+// if the word is probably in the filter.
+func (f *Filter) Test(s string) bool {
+	h1, h2 := hashes(s)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		idx := (h1 + i*h2) % f.m
+		if f.bits[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Len returns the number of items added to the filter.
+func (f *Filter) Len() int {
+	return int(f.n)
+}
+
+// WriteTo serializes the filter.
+func (f *Filter) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var cnt int64
+	hdr := make([]byte, 0, len(magic)+1+4+8+8)
+	hdr = append(hdr, magic...)
+	hdr = append(hdr, version)
+	hdr = binary.LittleEndian.AppendUint32(hdr, f.k)
+	hdr = binary.LittleEndian.AppendUint64(hdr, f.m)
+	hdr = binary.LittleEndian.AppendUint64(hdr, f.n)
+	c, err := bw.Write(hdr)
+	cnt += int64(c)
+	if err != nil {
+		return cnt, err
+	}
+	buf := make([]byte, 8)
+	for _, v := range f.bits {
+		binary.LittleEndian.PutUint64(buf, v)
+		c, err = bw.Write(buf)
+		cnt += int64(c)
+		if err != nil {
+			return cnt, err
+		}
+	}
+	return cnt, bw.Flush()
+}
+
+// Read deserializes a filter.
+func Read(r io.Reader) (*Filter, error) {
+	br := bufio.NewReader(r)
+	hdr := make([]byte, len(magic)+1+4+8+8)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		return nil, fmt.Errorf("-> io.ReadFull: %w", err)
+	}
+	if string(hdr[:len(magic)]) != magic || hdr[len(magic)] != version {
+		return nil, ErrFormat
+	}
+	hdr = hdr[len(magic)+1:]
+	f := &Filter{
+		k: binary.LittleEndian.Uint32(hdr),
+		m: binary.LittleEndian.Uint64(hdr[4:]),
+		n: binary.LittleEndian.Uint64(hdr[12:]),
+	}
+	if f.k == 0 || f.m == 0 {
+		return nil, ErrFormat
+	}
+	f.bits = make([]uint64, (f.m+63)/64)
+	buf := make([]byte, 8)
+	for i := range f.bits {
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, fmt.Errorf("-> io.ReadFull: %w", err)
+		}
+		f.bits[i] = binary.LittleEndian.Uint64(buf)
+	}
+	return f, nil
+}
+
+// Load reads a filter from a file.
+func Load(path string) (*Filter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Save writes a filter to a file.
+func (f *Filter) Save(path string) error {
+	w, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteTo(w)
+	if err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// hashes returns two hashes of a string for double hashing. The second hash
+// is derived from the first one by mixing its bits, so they are not
+// independent, but different enough for Kirsch-Mitzenmacher double hashing.
+func hashes(s string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	h1 := h.Sum64()
+	h2 := h1>>33 | h1<<31
+	h2 ^= 0x9e3779b97f4a7c15
+	h2 *= 0xff51afd7ed558ccd
+	h2 ^= h2 >> 33
+	// make sure the step is odd, so it never degenerates into zero.
+	return h1, h2 | 1
+}
@@ -0,0 +1,63 @@
+package bloom_test
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/gnames/gndict/pkg/bloom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	fp := 0.01
+	n := 10_000
+	bf := bloom.New(n, fp)
+	for i := range n {
+		bf.Add(fmt.Sprintf("word%d", i))
+	}
+
+	path := filepath.Join(t.TempDir(), "genera.csv"+bloom.Ext)
+	require.Nil(bf.Save(path))
+	res, err := bloom.Load(path)
+	require.Nil(err)
+	assert.Equal(n, res.Len())
+
+	for i := range n {
+		w := fmt.Sprintf("word%d", i)
+		assert.True(res.Test(w), w)
+	}
+
+	var fps int
+	tries := 100_000
+	for i := range tries {
+		if res.Test(fmt.Sprintf("other%d", i)) {
+			fps++
+		}
+	}
+	rate := float64(fps) / float64(tries)
+	assert.Less(rate, fp*2, "false-positive rate %v", rate)
+}
+
+func TestReadErrors(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		msg  string
+		data []byte
+	}{
+		{"wrong magic", []byte("XXXX\x01" + string(make([]byte, 20)))},
+		{"wrong version", []byte("GNBF\x02" + string(make([]byte, 20)))},
+		{"zero k and m", []byte("GNBF\x01" + string(make([]byte, 20)))},
+	}
+	for _, v := range tests {
+		_, err := bloom.Read(bytes.NewReader(v.data))
+		assert.ErrorIs(err, bloom.ErrFormat, v.msg)
+	}
+
+	_, err := bloom.Read(bytes.NewReader([]byte("GNBF")))
+	assert.NotNil(err, "short header")
+}
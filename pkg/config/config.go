@@ -1,8 +1,16 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gnames/gndict/internal/compress"
 	"github.com/gnames/gnsys"
-	"github.com/rs/zerolog/log"
 )
 
 type Config struct {
@@ -12,15 +20,149 @@ type Config struct {
 	PgPass        string
 	PgDb          string
 	ForceDownload bool
+
+	// Compression is the compression method of downloaded and preprocessed
+	// files in the cache: 'zstd' (default), 'gzip' or 'none'. Files are
+	// read according to their extension, whatever Compression is set.
+	Compression string
+
+	// DownloadJobs is the number of parallel queries that download names.
+	// If it is larger than 1, the names query is split into range scans
+	// by canonical_id.
+	DownloadJobs int
+
+	// DownloadRetries is the number of times a download query is repeated
+	// after a transient database error.
+	DownloadRetries int
+
+	// DataSources are IDs of data sources with names for the dictionary.
+	// If it is empty, names come from curated data sources and from data
+	// sources with IDs 11, 12 and 206. Downloaded names have to be removed
+	// with ForceDownload after DataSources change.
+	DataSources []int
+
+	// Bloom enables creation of Bloom filter files beside dictionary files.
+	Bloom bool
+
+	// BloomFPRate is a false-positive rate of Bloom filters.
+	BloomFPRate float64
+
+	// SQLite enables saving of the dictionary to a SQLite database.
+	SQLite bool
+
+	// ScoreAmbig is the ambiguity score (from 0 to 1) starting from which
+	// words go to the in-ambig dictionaries.
+	ScoreAmbig float64
+
+	// ScoreNotIn is the ambiguity score starting from which words are
+	// removed from dictionaries. If it is 0, words are never removed by
+	// their score.
+	ScoreNotIn float64
+
+	// PatternsFile is a path to a file with regular expressions or globs
+	// of words that cannot be parts of scientific names. If it is empty,
+	// no patterns are used.
+	PatternsFile string
+
+	// ExtraLists are files with words that are added to word lists. Keys
+	// are names of the lists from ExtraListNames. Every file of 'common'
+	// becomes one more list of common words, files of blacklists extend
+	// the embedded blacklists.
+	ExtraLists map[string][]string
+
+	// CurationFile keeps curators' decisions about blacklist candidates.
+	// Accepted words are added to blacklists. By default it is
+	// 'curation.tsv' in the CacheDir.
+	CurationFile string
+
+	// GreyRules are rules for grey words for uninomials, genera and species.
+	GreyRules map[string]GreyRule
+
+	// ASCIIFold enables creation of ASCII variants of words with
+	// diacritics and ligatures (for example 'Müller' -> 'Mueller', 'Muller').
+	ASCIIFold bool
+
+	// OCR enables creation of OCR-error variants of genera and species
+	// words.
+	OCR bool
+
+	// OCRMinScore is the smallest probability of an OCR error for a variant
+	// to be saved.
+	OCRMinScore float64
+
+	// GenderVariants enables generation of gender-agreement variants of
+	// specific epithets (for example 'alba' -> 'albus', 'album').
+	GenderVariants bool
+
+	// ShrinkLimits are the largest allowed shrinkages (in percent) of
+	// dictionary files (for example 'in/genera.csv') compared to the
+	// previous run. If a file shrinks more, the build fails.
+	ShrinkLimits map[string]float64
+
+	// BuildID is the name of the directory of a build inside of the
+	// BuildsDir. By default it is the UTC time when the Config was created.
+	BuildID string
+
+	// KeepBuilds is the number of the newest builds that are kept after
+	// a successful build, older builds are removed. The current build is
+	// always kept. Zero means all builds are kept.
+	KeepBuilds int
+
+	// Scope restricts the dictionary to names that have the Scope taxon
+	// in their classification (for example Plantae, Aves or Fagaceae).
+	// Empty Scope means all names are used.
+	Scope string
 }
 
+// GreyRule contains rules that move words of a category (uninomials,
+// genera or species) to in-ambig dictionaries. A word is grey if any of
+// the rules applies to it.
+type GreyRule struct {
+	// MinLen is the smallest number of letters for a word to be white.
+	// Zero value means the default length of 4.
+	MinLen int
+
+	// IgnoreCommon turns off the rule that makes grey words that are in
+	// common-words lists.
+	IgnoreCommon bool
+
+	// Deny is a list of regular expressions. Matching words are grey.
+	Deny []string
+
+	// MinCount is the smallest number of names with the word for the word
+	// to be white. Zero turns off the rule.
+	MinCount int
+
+	// Score is the ambiguity score starting from which words are grey.
+	// Zero value means the ScoreAmbig setting is used.
+	Score float64
+}
+
+// BuildIDFormat is the time format of default build IDs. Milliseconds
+// keep apart IDs of runs started within the same second.
+const BuildIDFormat = "20060102-150405.000"
+
+// DefaultShrinkLimit is the default largest allowed shrinkage (in percent)
+// of 'in' dictionaries.
+const DefaultShrinkLimit = 5.0
+
+// DefaultMinLen is the default smallest length of a white word.
+const DefaultMinLen = 4
+
+// Categories of words that have their own grey rules.
+var Categories = []string{"uninomials", "genera", "species"}
+
+// ExtraListNames are names of word lists that can be extended by
+// ExtraLists.
+var ExtraListNames = []string{"common", "species-black", "uninomials-black"}
+
 type Option func(*Config)
 
 func OptCacheDir(s string) Option {
 	return func(cfg *Config) {
-		s, err := gnsys.ConvertTilda(s)
-		if err != nil {
-			log.Fatal().Err(err).Msg("")
+		path, err := gnsys.ConvertTilda(s)
+		if err == nil {
+			s = path
 		}
 		cfg.CacheDir = s
 	}
@@ -56,6 +198,309 @@ func OptForceDownload(b bool) Option {
 	}
 }
 
+func OptCompression(s string) Option {
+	return func(cfg *Config) {
+		cfg.Compression = strings.ToLower(strings.TrimSpace(s))
+	}
+}
+
+func OptDownloadJobs(i int) Option {
+	return func(cfg *Config) {
+		cfg.DownloadJobs = i
+	}
+}
+
+func OptDownloadRetries(i int) Option {
+	return func(cfg *Config) {
+		cfg.DownloadRetries = i
+	}
+}
+
+func OptDataSources(ids []int) Option {
+	return func(cfg *Config) {
+		cfg.DataSources = ids
+	}
+}
+
+func OptBloom(b bool) Option {
+	return func(cfg *Config) {
+		cfg.Bloom = b
+	}
+}
+
+func OptBloomFPRate(f float64) Option {
+	return func(cfg *Config) {
+		cfg.BloomFPRate = f
+	}
+}
+
+func OptSQLite(b bool) Option {
+	return func(cfg *Config) {
+		cfg.SQLite = b
+	}
+}
+
+func OptScoreAmbig(f float64) Option {
+	return func(cfg *Config) {
+		cfg.ScoreAmbig = f
+	}
+}
+
+func OptScoreNotIn(f float64) Option {
+	return func(cfg *Config) {
+		cfg.ScoreNotIn = f
+	}
+}
+
+func OptASCIIFold(b bool) Option {
+	return func(cfg *Config) {
+		cfg.ASCIIFold = b
+	}
+}
+
+func OptOCR(b bool) Option {
+	return func(cfg *Config) {
+		cfg.OCR = b
+	}
+}
+
+func OptOCRMinScore(f float64) Option {
+	return func(cfg *Config) {
+		cfg.OCRMinScore = f
+	}
+}
+
+func OptGenderVariants(b bool) Option {
+	return func(cfg *Config) {
+		cfg.GenderVariants = b
+	}
+}
+
+// OptGreyRules sets grey rules for categories. Categories that are not
+// in the map keep their default rules.
+func OptGreyRules(rules map[string]GreyRule) Option {
+	return func(cfg *Config) {
+		for k, v := range rules {
+			k = strings.ToLower(k)
+			if v.MinLen == 0 {
+				v.MinLen = DefaultMinLen
+			}
+			cfg.GreyRules[k] = v
+		}
+	}
+}
+
+// OptShrinkLimits sets the largest allowed shrinkages of dictionary files
+// in percent. Files that are not in the map keep their default limits,
+// zero or negative limit turns the check off for a file. Files without
+// extension (for example 'in/genera') are considered to be CSV files.
+func OptShrinkLimits(limits map[string]float64) Option {
+	return func(cfg *Config) {
+		for k, v := range limits {
+			k = filepath.ToSlash(k)
+			if filepath.Ext(k) == "" {
+				k += ".csv"
+			}
+			if v <= 0 {
+				delete(cfg.ShrinkLimits, k)
+				continue
+			}
+			cfg.ShrinkLimits[k] = v
+		}
+	}
+}
+
+func OptPatternsFile(s string) Option {
+	return func(cfg *Config) {
+		path, err := gnsys.ConvertTilda(s)
+		if err == nil {
+			s = path
+		}
+		cfg.PatternsFile = s
+	}
+}
+
+func OptExtraLists(lists map[string][]string) Option {
+	return func(cfg *Config) {
+		res := make(map[string][]string, len(lists))
+		for k, v := range lists {
+			paths := make([]string, len(v))
+			for i, p := range v {
+				path, err := gnsys.ConvertTilda(p)
+				if err != nil {
+					path = p
+				}
+				paths[i] = path
+			}
+			res[strings.ToLower(k)] = paths
+		}
+		cfg.ExtraLists = res
+	}
+}
+
+func OptCurationFile(s string) Option {
+	return func(cfg *Config) {
+		path, err := gnsys.ConvertTilda(s)
+		if err == nil {
+			s = path
+		}
+		cfg.CurationFile = s
+	}
+}
+
+func OptBuildID(s string) Option {
+	return func(cfg *Config) {
+		cfg.BuildID = s
+	}
+}
+
+func OptKeepBuilds(i int) Option {
+	return func(cfg *Config) {
+		cfg.KeepBuilds = i
+	}
+}
+
+func OptScope(s string) Option {
+	return func(cfg *Config) {
+		cfg.Scope = strings.TrimSpace(s)
+	}
+}
+
+// ScopeDir returns a directory name for the Scope, or an empty string
+// if the Scope is not set. The name only contains lowercase ASCII letters,
+// digits, '-' and '_', so it can never point outside of the CacheDir.
+func (cfg Config) ScopeDir() string {
+	s := strings.Join(strings.Fields(strings.ToLower(cfg.Scope)), "_")
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// WorkDir returns the directory for downloaded and preprocessed files.
+// Every scope has its own WorkDir inside of the CacheDir.
+func (cfg Config) WorkDir() string {
+	if cfg.Scope == "" {
+		return cfg.CacheDir
+	}
+	return filepath.Join(cfg.CacheDir, "scopes", cfg.ScopeDir())
+}
+
+// BuildsDir returns the directory that keeps all builds of dictionaries.
+func (cfg Config) BuildsDir() string {
+	return filepath.Join(cfg.WorkDir(), "builds")
+}
+
+// BuildDir returns the directory where dictionaries of the BuildID are
+// saved.
+func (cfg Config) BuildDir() string {
+	return filepath.Join(cfg.BuildsDir(), cfg.BuildID)
+}
+
+// DictDir returns the path to dictionaries of the current build. It is
+// a symbolic link that changes only after a successful build.
+func (cfg Config) DictDir() string {
+	return filepath.Join(cfg.WorkDir(), "dict")
+}
+
+// ErrInvalid is wrapped by all errors returned from Validate.
+var ErrInvalid = errors.New("invalid configuration")
+
+// Validate checks that settings of the configuration make sense.
+// It returns all found problems joined in one error.
+func (cfg Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		err := fmt.Errorf(format, args...)
+		errs = append(errs, fmt.Errorf("%w: %w", ErrInvalid, err))
+	}
+
+	switch {
+	case cfg.CacheDir == "":
+		add("CacheDir is empty")
+	case strings.HasPrefix(cfg.CacheDir, "~"):
+		add("cannot find home directory for CacheDir '%s'", cfg.CacheDir)
+	}
+	if cfg.PgHost == "" {
+		add("PgHost is empty")
+	}
+	if cfg.PgDb == "" {
+		add("PgDb is empty")
+	}
+	if !slices.Contains(compress.Methods, cfg.Compression) {
+		add("Compression must be one of %s, got '%s'",
+			strings.Join(compress.Methods, ", "), cfg.Compression)
+	}
+	if cfg.DownloadJobs < 1 {
+		add("DownloadJobs must be at least 1, got %d", cfg.DownloadJobs)
+	}
+	if cfg.DownloadRetries < 0 {
+		add("DownloadRetries cannot be negative, got %d", cfg.DownloadRetries)
+	}
+	for _, v := range cfg.DataSources {
+		if v < 1 {
+			add("DataSources IDs must be positive, got %d", v)
+		}
+	}
+	switch {
+	case cfg.BuildID == "":
+		add("BuildID is empty")
+	case cfg.BuildID != filepath.Base(cfg.BuildID) || cfg.BuildID == "current" ||
+		strings.HasPrefix(cfg.BuildID, "."):
+		add("BuildID '%s' cannot be used as a directory name", cfg.BuildID)
+	}
+	if cfg.KeepBuilds < 0 {
+		add("KeepBuilds cannot be negative, got %d", cfg.KeepBuilds)
+	}
+	switch {
+	case strings.ContainsAny(cfg.Scope, `/\.`):
+		add("Scope '%s' cannot contain '/', '\\' or '.'", cfg.Scope)
+	case cfg.Scope != "" && cfg.ScopeDir() == "":
+		add("Scope '%s' has no letters or digits", cfg.Scope)
+	}
+	if cfg.BloomFPRate <= 0 || cfg.BloomFPRate >= 1 {
+		add("BloomFPRate must be between 0 and 1, got %v", cfg.BloomFPRate)
+	}
+	for _, v := range []struct {
+		name string
+		val  float64
+	}{
+		{"ScoreAmbig", cfg.ScoreAmbig},
+		{"ScoreNotIn", cfg.ScoreNotIn},
+		{"OCRMinScore", cfg.OCRMinScore},
+	} {
+		if v.val < 0 || v.val > 1 {
+			add("%s must be from 0 to 1, got %v", v.name, v.val)
+		}
+	}
+	for k := range cfg.ExtraLists {
+		if !slices.Contains(ExtraListNames, k) {
+			add("unknown ExtraLists list '%s', use one of %s",
+				k, strings.Join(ExtraListNames, ", "))
+		}
+	}
+	for k, v := range cfg.GreyRules {
+		if !slices.Contains(Categories, k) {
+			add("unknown GreyRules category '%s'", k)
+		}
+		if v.MinLen < 0 || v.MinCount < 0 {
+			add("GreyRules of '%s' cannot have negative values", k)
+		}
+		if v.Score < 0 || v.Score > 1 {
+			add("GreyRules Score of '%s' must be from 0 to 1, got %v", k, v.Score)
+		}
+		for _, d := range v.Deny {
+			if _, err := regexp.Compile(d); err != nil {
+				add("GreyRules Deny of '%s': %v", k, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func New(opts ...Option) Config {
 	cacheDir, _ := gnsys.ConvertTilda("~/.cache/gndict")
 	res := Config{
@@ -64,9 +509,32 @@ func New(opts ...Option) Config {
 		PgUser:   "postgres",
 		PgPass:   "postgres",
 		PgDb:     "gnames",
+
+		Compression:     compress.Zstd,
+		DownloadJobs:    1,
+		DownloadRetries: 3,
+
+		BuildID:    time.Now().UTC().Format(BuildIDFormat),
+		KeepBuilds: 3,
+
+		BloomFPRate: 0.01,
+		ScoreAmbig:  0.4,
+		OCRMinScore: 0.1,
+		GreyRules:   make(map[string]GreyRule),
+		ShrinkLimits: map[string]float64{
+			"in/uninomials.csv": DefaultShrinkLimit,
+			"in/genera.csv":     DefaultShrinkLimit,
+			"in/species.csv":    DefaultShrinkLimit,
+		},
+	}
+	for _, v := range Categories {
+		res.GreyRules[v] = GreyRule{MinLen: DefaultMinLen}
 	}
 	for _, opt := range opts {
 		opt(&res)
 	}
+	if res.CurationFile == "" {
+		res.CurationFile = filepath.Join(res.CacheDir, "curation.tsv")
+	}
 	return res
 }
@@ -0,0 +1,80 @@
+package config_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gnames/gndict/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeDir(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		msg, scope, dir string
+	}{
+		{"empty", "", ""},
+		{"lowercase", "Plantae", "plantae"},
+		{"spaces", " Homo  sapiens ", "homo_sapiens"},
+		{"parent", "..", ""},
+		{"path", "../../x", "x"},
+		{"backslash", `a\b`, "ab"},
+		{"non-ascii", "Aëdes", "ades"},
+	}
+	for _, v := range tests {
+		cfg := config.New(config.OptCacheDir("/tmp/c"), config.OptScope(v.scope))
+		assert.Equal(v.dir, cfg.ScopeDir(), v.msg)
+		if v.dir != "" {
+			assert.Equal(filepath.Join("/tmp/c", "scopes", v.dir), cfg.WorkDir(), v.msg)
+		}
+	}
+}
+
+func TestValidateScope(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		msg, scope string
+		valid      bool
+	}{
+		{"empty", "", true},
+		{"taxon", "Plantae", true},
+		{"two words", "Homo sapiens", true},
+		{"dot", ".", false},
+		{"parent", "..", false},
+		{"slash", "../..", false},
+		{"backslash", `a\b`, false},
+		{"no letters", "???", false},
+	}
+	for _, v := range tests {
+		cfg := config.New(config.OptCacheDir("/tmp/c"), config.OptScope(v.scope))
+		err := cfg.Validate()
+		if v.valid {
+			assert.Nil(err, v.msg)
+			continue
+		}
+		assert.ErrorIs(err, config.ErrInvalid, v.msg)
+	}
+}
+
+func TestValidateDataSources(t *testing.T) {
+	assert := assert.New(t)
+	cfg := config.New(config.OptDataSources([]int{1, 169}))
+	assert.Nil(cfg.Validate())
+	cfg = config.New(config.OptDataSources([]int{1, 0}))
+	assert.ErrorIs(cfg.Validate(), config.ErrInvalid)
+}
+
+func TestValidateExtraLists(t *testing.T) {
+	assert := assert.New(t)
+	cfg := config.New(config.OptExtraLists(map[string][]string{
+		"Common":        {"~/de.txt"},
+		"species-black": {"/tmp/sp.txt"},
+	}))
+	assert.Nil(cfg.Validate())
+	assert.NotContains(cfg.ExtraLists["common"][0], "~")
+
+	cfg = config.New(config.OptExtraLists(map[string][]string{
+		"genera": {"/tmp/g.txt"},
+	}))
+	assert.ErrorIs(cfg.Validate(), config.ErrInvalid)
+}
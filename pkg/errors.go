@@ -0,0 +1,21 @@
+package gndict
+
+import "github.com/gnames/gndict/internal/ent"
+
+// Errors returned by DictGen methods. Use errors.Is to check for them.
+var (
+	// ErrNoDatabase means that the gnames database cannot be reached.
+	ErrNoDatabase = ent.ErrNoDatabase
+
+	// ErrCacheMissing means that a file of a previous stage is not in the
+	// cache, for example Output runs before Download or Preprocess.
+	ErrCacheMissing = ent.ErrCacheMissing
+
+	// ErrEmptyDownload means that the database returned no names or genera,
+	// for example because of a wrong scope or an empty database.
+	ErrEmptyDownload = ent.ErrEmptyDownload
+
+	// ErrBuildExists means that the directory of the BuildID is already
+	// in the BuildsDir.
+	ErrBuildExists = ent.ErrBuildExists
+)
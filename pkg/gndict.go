@@ -1,10 +1,15 @@
 package gndict
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/gnames/gndict/internal/ent"
 	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/gnames/gndict/internal/progress"
 	"github.com/gnames/gndict/pkg/config"
 	"github.com/rs/zerolog/log"
 )
@@ -13,38 +18,163 @@ type gndict struct {
 	cfg config.Config
 	sys ent.Sys
 	dat *data.Data
+	st  ent.Store
 	ent.Downloader
+
+	// run keeps durations of stages.
+	run *progress.Run
+	// files and counts are numbers of entries of created dictionary files.
+	files  []string
+	counts map[string]int
 }
 
+// New creates a DictGen instance. The st argument is optional, if it is not
+// nil, the dictionary is also saved to a database. It returns an error if
+// the CurationFile exists but cannot be read, so curators' decisions are
+// never lost silently, or if a file of ExtraLists cannot be read.
 func New(
 	cfg config.Config,
 	dl ent.Downloader,
 	sys ent.Sys,
-) DictGen {
+	st ent.Store,
+) (DictGen, error) {
 	dat := data.New()
-	return &gndict{cfg: cfg, Downloader: dl, sys: sys, dat: dat}
+	ds, err := data.ReadCuration(cfg.CurationFile)
+	if err != nil {
+		err = fmt.Errorf("-> data.ReadCuration %s: %w", cfg.CurationFile, err)
+		return nil, err
+	}
+	dat.Merge(ds)
+	for _, k := range slices.Sorted(maps.Keys(cfg.ExtraLists)) {
+		for _, path := range cfg.ExtraLists[k] {
+			err = dat.AddFile(k, path)
+			if err != nil {
+				err = fmt.Errorf("-> dat.AddFile %s: %w", path, err)
+				return nil, err
+			}
+		}
+	}
+	res := gndict{
+		cfg:        cfg,
+		Downloader: dl,
+		sys:        sys,
+		st:         st,
+		dat:        dat,
+		run:        progress.NewRun(),
+	}
+	return &res, nil
 }
 
-func (d *gndict) Download() error {
-	return d.Downloader.Download(d.dat)
+func (d *gndict) Download(ctx context.Context) error {
+	ctx = progress.WithRun(ctx, d.run)
+	return d.Downloader.Download(ctx, d.dat)
 }
 
-func (d *gndict) Preprocess() error {
+func (d *gndict) Preprocess(ctx context.Context) error {
 	log.Info().Msg("Start Preprocessing")
-	ppr, err := ent.NewPreproc(d.cfg, d.sys, d.dat)
+	ctx = progress.WithRun(ctx, d.run)
+	ppr, err := ent.NewPreproc(ctx, d.cfg, d.sys, d.dat)
 	if err != nil {
 		err = fmt.Errorf("-> ent.NewPreproc: %w", err)
 		return err
 	}
-	return ppr.Preprocess()
+	return ppr.Preprocess(ctx)
 }
 
-func (d *gndict) Output() error {
+func (d *gndict) Output(ctx context.Context) error {
 	log.Info().Msg("Creating Output")
-	o, err := ent.NewOutput(d.cfg, d.sys, d.dat)
+	ctx = progress.WithRun(ctx, d.run)
+	o, err := ent.NewOutput(d.cfg, d.sys, d.dat, d.st)
 	if err != nil {
 		err = fmt.Errorf("-> ent.NewOutput: %w", err)
 		return err
 	}
-	return o.Create()
+	err = o.Create(ctx)
+	if err != nil {
+		return err
+	}
+	d.files, d.counts = o.Entries()
+
+	rep, err := o.Report(ctx)
+	if err != nil {
+		err = fmt.Errorf("-> o.Report: %w", err)
+		return err
+	}
+	err = o.SaveReport(rep)
+	if err != nil {
+		err = fmt.Errorf("-> o.SaveReport: %w", err)
+		return err
+	}
+
+	err = ent.Activate(d.cfg, d.cfg.BuildID)
+	if err != nil {
+		err = fmt.Errorf("-> ent.Activate: %w", err)
+		return err
+	}
+	log.Info().Msgf("Build %s is current", d.cfg.BuildID)
+
+	ids, err := ent.Prune(d.cfg, d.cfg.KeepBuilds)
+	if err != nil {
+		err = fmt.Errorf("-> ent.Prune: %w", err)
+		return err
+	}
+	if len(ids) > 0 {
+		log.Info().Msgf("Removed old builds: %s", strings.Join(ids, ", "))
+	}
+	return nil
+}
+
+func (d *gndict) Summary() Summary {
+	return Summary{
+		Timings: d.run.Timings(),
+		Files:   d.files,
+		Entries: d.counts,
+	}
+}
+
+func (d *gndict) Builds() ([]ent.Build, error) {
+	return ent.Builds(d.cfg)
+}
+
+func (d *gndict) Rollback(id string) (string, error) {
+	return ent.Rollback(d.cfg, id)
+}
+
+func (d *gndict) Prune(keep int) ([]string, error) {
+	return ent.Prune(d.cfg, keep)
+}
+
+func (d *gndict) CacheStatus(ctx context.Context) ([]ent.CacheFile, error) {
+	return ent.CacheStatus(ctx, d.cfg)
+}
+
+func (d *gndict) CleanCache(stages ...string) ([]string, error) {
+	return ent.CleanCache(d.cfg, stages...)
+}
+
+func (d *gndict) Explain(
+	ctx context.Context,
+	words ...string,
+) ([]ent.Explanation, error) {
+	return ent.Explain(ctx, d.cfg, d.sys, d.dat, words)
+}
+
+func (d *gndict) MatchPattern(pattern string) ([]ent.PatternMatch, error) {
+	return ent.MatchPattern(d.cfg, pattern)
+}
+
+func (d *gndict) Curate(
+	ctx context.Context,
+	minCount int,
+) ([]ent.Candidate, error) {
+	return ent.Candidates(ctx, d.cfg, d.sys, d.dat, minCount)
+}
+
+func (d *gndict) Decide(ds []data.Decision) error {
+	err := data.AppendCuration(d.cfg.CurationFile, ds)
+	if err != nil {
+		return err
+	}
+	d.dat.Merge(ds)
+	return nil
 }
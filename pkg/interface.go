@@ -1,7 +1,41 @@
 package gndict
 
+import "context"
+
 type DictGen interface {
-	Download() error
-	Preprocess() error
-	Output() error
+	// Download saves names and genera from the database to the cache.
+	Download(ctx context.Context) error
+	// Preprocess splits downloaded names into uninomials, genera and
+	// species.
+	Preprocess(ctx context.Context) error
+	// Output creates dictionaries from preprocessed data.
+	Output(ctx context.Context) error
+	// Summary returns durations of finished stages and the numbers of
+	// entries in dictionary files created by Output.
+	Summary() Summary
+	// Explain shows how words are classified by grey rules of every
+	// category.
+	Explain(ctx context.Context, words ...string) ([]Explanation, error)
+	// MatchPattern finds words of the current dictionary that match
+	// a blacklist pattern.
+	MatchPattern(pattern string) ([]PatternMatch, error)
+	// Curate finds candidates for blacklists. Epithets that are common
+	// words are proposed if their count is at least minCount.
+	Curate(ctx context.Context, minCount int) ([]Candidate, error)
+	// Builds returns builds of dictionaries from the oldest to the newest.
+	Builds() ([]DictBuild, error)
+	// Rollback makes a build current. If id is empty, the build made before
+	// the current one is used. It returns the ID of the current build.
+	Rollback(id string) (string, error)
+	// Prune removes all builds except the keep newest ones and the current
+	// one. It returns IDs of removed builds.
+	Prune(keep int) ([]string, error)
+	// CacheStatus describes files created by every stage of the pipeline.
+	CacheStatus(ctx context.Context) ([]CacheFile, error)
+	// CleanCache removes files of the stages, or of all stages if none are
+	// given. It returns paths of removed files.
+	CleanCache(stages ...string) ([]string, error)
+	// Decide saves curation decisions to the curation file, so accepted
+	// words are added to blacklists on the next build.
+	Decide(ds []Decision) error
 }
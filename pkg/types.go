@@ -0,0 +1,78 @@
+package gndict
+
+import (
+	"github.com/gnames/gndict/internal/ent"
+	"github.com/gnames/gndict/internal/ent/data"
+	"github.com/gnames/gndict/internal/progress"
+)
+
+// Types returned by DictGen methods.
+type (
+	// Explanation describes how a word is classified in a category.
+	Explanation = ent.Explanation
+
+	// Check is a result of applying one classification rule to a word.
+	Check = ent.Check
+
+	// Record is a word of a dictionary with its classification.
+	Record = ent.Record
+
+	// Bucket describes which part of the dictionary a word belongs to.
+	Bucket = ent.Bucket
+
+	// PatternMatch is a word of the current dictionary that matches
+	// a blacklist pattern.
+	PatternMatch = ent.PatternMatch
+
+	// Candidate is a word proposed for a blacklist.
+	Candidate = ent.Candidate
+
+	// Decision is a curator's decision about a blacklist candidate.
+	Decision = data.Decision
+
+	// DictBuild is a generation of dictionaries kept in the cache. It is not
+	// called Build, because Build keeps the build time of the program.
+	DictBuild = ent.Build
+
+	// CacheFile describes a file of the cache created by one of the stages.
+	CacheFile = ent.CacheFile
+
+	// Timing is the duration of a finished stage.
+	Timing = progress.Timing
+)
+
+// Summary describes a run of the pipeline.
+type Summary struct {
+	// Timings are durations of finished stages in the order they finished.
+	Timings []Timing
+
+	// Files are dictionary files created by Output.
+	Files []string
+
+	// Entries are the numbers of entries of dictionary files.
+	Entries map[string]int
+}
+
+// Buckets of dictionary words.
+const (
+	In      = ent.In
+	InAmbig = ent.InAmbig
+	NotIn   = ent.NotIn
+	Common  = ent.Common
+)
+
+// Stages of the pipeline that leave files in the cache.
+const (
+	StageDownload   = ent.StageDownload
+	StagePreprocess = ent.StagePreprocess
+	StageOutput     = ent.StageOutput
+)
+
+// Stages are all stages of the pipeline in the order they run.
+var Stages = ent.Stages
+
+// Blacklists that take curation decisions.
+const (
+	SpeciesBlack    = data.SpeciesBlack
+	UninomialsBlack = data.UninomialsBlack
+)